@@ -0,0 +1,61 @@
+package directedgraph
+
+import (
+	"fmt"
+)
+
+// operation is a single step of building a graph
+type operation func(g *DirectedGraph) error
+
+// Builder accumulates nodes and edges and builds a graph from them. Builders are
+// immutable: Node and Edge return a new builder and leave the receiver
+// unchanged, so a builder can be used as a common base for several graphs.
+type Builder struct {
+	ops []operation
+}
+
+// NewBuilder creates a new builder for an empty graph
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// with returns a new builder with op appended to the operations of b
+func (b *Builder) with(op operation) *Builder {
+	ops := make([]operation, len(b.ops), len(b.ops)+1)
+	copy(ops, b.ops)
+	return &Builder{ops: append(ops, op)}
+}
+
+// Node returns a new builder that additionally adds a node
+func (b *Builder) Node(key string, value interface{}) *Builder {
+	return b.with(func(g *DirectedGraph) error {
+		if err := g.NewNode(key, value); err != nil {
+			return fmt.Errorf("node `%v`: %w", key, err)
+		}
+		return nil
+	})
+}
+
+// Edge returns a new builder that additionally adds an edge between two nodes
+func (b *Builder) Edge(from, to string) *Builder {
+	return b.with(func(g *DirectedGraph) error {
+		if err := g.NewEdge(from, to); err != nil {
+			return fmt.Errorf("edge `%v`->`%v`: %w", from, to, err)
+		}
+		return nil
+	})
+}
+
+// Build creates a new graph and applies all operations in the order they have
+// been added to the builder. It returns the first error encountered, e.g. an
+// error wrapping ErrorNodeAlreadyExists for duplicate nodes or
+// ErrorNodeNotFound for edges between unknown nodes.
+func (b *Builder) Build() (*DirectedGraph, error) {
+	g := New()
+	for _, op := range b.ops {
+		if err := op(g); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,64 @@
+package directedgraph
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Run("empty graph", func(t *testing.T) {
+		g, err := NewBuilder().Build()
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if len(g.Nodes()) != 0 {
+			t.Errorf("expected empty graph")
+		}
+	})
+	t.Run("regular graph", func(t *testing.T) {
+		g, err := NewBuilder().Node("a", 1).Node("b", 2).Edge("a", "b").Build()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if value, _ := g.Value("b"); value != 2 {
+			t.Errorf("expected node value `2`, got `%v`", value)
+		}
+		if !g.HasEdge("a", "b") {
+			t.Errorf("expected edge `a`->`b` not found.")
+		}
+	})
+	t.Run("immutable", func(t *testing.T) {
+		base := NewBuilder().Node("a", 1).Node("b", 2)
+		x := base.Node("x", nil).Edge("a", "x")
+		y := base.Node("y", nil).Edge("b", "y")
+		gx, err := x.Build()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		gy, err := y.Build()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(gx.Nodes()) != 3 || len(gy.Nodes()) != 3 {
+			t.Errorf("unexpected nodes `%v` and `%v`", gx.Nodes(), gy.Nodes())
+		}
+		if _, err := gx.Value("y"); err != ErrorNodeNotFound {
+			t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+		}
+		if g, _ := base.Build(); len(g.Nodes()) != 2 {
+			t.Errorf("base builder modified, got nodes `%v`", g.Nodes())
+		}
+	})
+	t.Run("duplicate node", func(t *testing.T) {
+		_, err := NewBuilder().Node("a", 1).Node("a", 2).Build()
+		if !errors.Is(err, ErrorNodeAlreadyExists) {
+			t.Errorf("expected `%v` got `%v`", ErrorNodeAlreadyExists, err)
+		}
+	})
+	t.Run("unknown node", func(t *testing.T) {
+		_, err := NewBuilder().Node("a", 1).Edge("a", "b").Node("b", 2).Build()
+		if !errors.Is(err, ErrorNodeNotFound) {
+			t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+		}
+	})
+}
@@ -4,8 +4,15 @@ package directedgraph
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"iter"
+	"reflect"
+	"sort"
+	"strconv"
 	"sync"
+
+	"github.com/danrl/golibby/queue"
 )
 
 var (
@@ -16,20 +23,76 @@ var (
 	// ErrorGraphIsCyclic is returned when trying to perform an operation on a
 	// cyclic graph that requires the graph to be acyclic
 	ErrorGraphIsCyclic = fmt.Errorf("graph is cyclic")
+	// ErrorEdgeNotFound is returned when trying to access a non-existent edge
+	ErrorEdgeNotFound = fmt.Errorf("edge not found")
+	// ErrorNoCycle is returned when a cycle was expected but not found
+	ErrorNoCycle = fmt.Errorf("no cycle")
+	// ErrorGraphInconsistent is returned when the internal state of a graph
+	// violates its invariants
+	ErrorGraphInconsistent = fmt.Errorf("graph inconsistent")
+	// ErrorGraphTooLarge is returned when a graph exceeds the size supported by
+	// an operation
+	ErrorGraphTooLarge = fmt.Errorf("graph too large")
+	// ErrorValueConflict is returned when nodes with the same key hold
+	// different values and there is no way to resolve the conflict
+	ErrorValueConflict = fmt.Errorf("value conflict")
+	// ErrorNoPath is returned when a path between two nodes was expected but
+	// not found
+	ErrorNoPath = fmt.Errorf("no path")
+	// ErrorUnsupportedVersion is returned when decoding data in a format
+	// version that is not supported
+	ErrorUnsupportedVersion = fmt.Errorf("unsupported version")
 )
 
-// DirectedGraph holds a directed graph data structure
+// DefaultEdgeWeight is the weight of edges that have not been assigned a weight
+const DefaultEdgeWeight = 1.0
+
+// node holds the value of a node. Each node has its own lock so that values of
+// distinct nodes can be updated concurrently.
+type node struct {
+	lock  sync.RWMutex
+	value interface{}
+}
+
+// get returns the value of the node
+func (n *node) get() interface{} {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	return n.value
+}
+
+// set updates the value of the node
+func (n *node) set(value interface{}) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.value = value
+}
+
+// DirectedGraph holds a directed graph data structure. It is safe for
+// concurrent use. Operations that change the structure of the graph lock the
+// whole graph, while UpdateValue only locks the node it updates. Hence, values
+// of distinct nodes can be updated in parallel.
 type DirectedGraph struct {
 	lock  sync.RWMutex
-	nodes map[string]interface{}
+	nodes map[string]*node
 	edges map[string]map[string]bool
+	// weights holds the weights of edges that have been assigned one, all
+	// other edges weigh DefaultEdgeWeight
+	weights         map[string]map[string]float64
+	onWeightChanged func(from, to string, oldWeight, newWeight float64)
+	redundantEdges  int
+	defaultValue    func(key string) interface{}
+	// tags holds the tags of nodes that have been assigned any
+	tags map[string]map[string]bool
 }
 
 // New initializes a new graph
 func New() *DirectedGraph {
 	return &DirectedGraph{
-		nodes: make(map[string]interface{}),
-		edges: make(map[string]map[string]bool),
+		nodes:   make(map[string]*node),
+		edges:   make(map[string]map[string]bool),
+		weights: make(map[string]map[string]float64),
+		tags:    make(map[string]map[string]bool),
 	}
 }
 
@@ -41,7 +104,7 @@ func (g *DirectedGraph) NewNode(key string, value interface{}) error {
 	if _, ok := g.nodes[key]; ok {
 		return ErrorNodeAlreadyExists
 	}
-	g.nodes[key] = value
+	g.nodes[key] = &node{value: value}
 	g.edges[key] = make(map[string]bool)
 
 	return nil
@@ -52,25 +115,64 @@ func (g *DirectedGraph) Value(key string) (interface{}, error) {
 	g.lock.RLock()
 	defer g.lock.RUnlock()
 
-	if _, ok := g.nodes[key]; !ok {
+	n, ok := g.nodes[key]
+	if !ok {
 		return nil, ErrorNodeNotFound
 	}
-	value := g.nodes[key]
-	return value, nil
+	return n.get(), nil
+}
+
+// Values retrieves the values assigned to the nodes identified by keys. If any
+// of the nodes does not exist, an error wrapping ErrorNodeNotFound is returned.
+func (g *DirectedGraph) Values(keys []string) (map[string]interface{}, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	values := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		n, ok := g.nodes[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: `%v`", ErrorNodeNotFound, key)
+		}
+		values[key] = n.get()
+	}
+	return values, nil
 }
 
-// UpdateValue sets the value of the node identified by key
+// UpdateValue sets the value of the node identified by key. It does not block
+// concurrent calls of UpdateValue for other nodes.
 func (g *DirectedGraph) UpdateValue(key string, value interface{}) error {
-	g.lock.Lock()
-	defer g.lock.Unlock()
+	g.lock.RLock()
+	defer g.lock.RUnlock()
 
-	if _, ok := g.nodes[key]; !ok {
+	n, ok := g.nodes[key]
+	if !ok {
 		return ErrorNodeNotFound
 	}
-	g.nodes[key] = value
+	n.set(value)
 	return nil
 }
 
+// UpdateValueCAS sets the value of the node identified by key only if its
+// current value deeply equals expected (see reflect.DeepEqual). It returns true
+// if the value has been updated. Like UpdateValue it only locks the node.
+func (g *DirectedGraph) UpdateValueCAS(key string, expected, value interface{}) (bool, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	n, ok := g.nodes[key]
+	if !ok {
+		return false, ErrorNodeNotFound
+	}
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if !reflect.DeepEqual(n.value, expected) {
+		return false, nil
+	}
+	n.value = value
+	return true, nil
+}
+
 // NewEdge adds an edge between to nodes in the graph
 func (g *DirectedGraph) NewEdge(from, to string) error {
 	g.lock.Lock()
@@ -83,15 +185,69 @@ func (g *DirectedGraph) NewEdge(from, to string) error {
 		return ErrorNodeNotFound
 	}
 
+	if g.edges[from][to] {
+		g.redundantEdges++
+	}
 	g.edges[from][to] = true
 	return nil
 }
 
-// Edges returns the keys of nodes that are directly connected to the node
+// SetDefaultValue sets a function that provides the value of nodes that are
+// created implicitly, e.g. by NewEdgeOrCreate. Without such a function, or if
+// f is nil, implicitly created nodes hold nil. The graph is locked while f is
+// called, so f must not use the graph.
+func (g *DirectedGraph) SetDefaultValue(f func(key string) interface{}) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.defaultValue = f
+}
+
+// ensureNode creates the node identified by key with the default value unless
+// it exists already. The caller must hold the write lock.
+func (g *DirectedGraph) ensureNode(key string) {
+	if _, ok := g.nodes[key]; ok {
+		return
+	}
+	var value interface{}
+	if g.defaultValue != nil {
+		value = g.defaultValue(key)
+	}
+	g.nodes[key] = &node{value: value}
+	g.edges[key] = make(map[string]bool)
+}
+
+// NewEdgeOrCreate adds an edge between two nodes in the graph and creates the
+// nodes if they do not exist yet. Nodes created are assigned the default value
+// (see SetDefaultValue).
+func (g *DirectedGraph) NewEdgeOrCreate(from, to string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.ensureNode(from)
+	g.ensureNode(to)
+	if g.edges[from][to] {
+		g.redundantEdges++
+	}
+	g.edges[from][to] = true
+}
+
+// RedundantEdgeCount returns how many times NewEdge has been called for an edge
+// that already existed
+func (g *DirectedGraph) RedundantEdgeCount() int {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.redundantEdges
+}
+
+// Edges returns the keys of nodes that are directly connected to the node. The
+// keys are a consistent snapshot of the graph, even if it is modified
+// concurrently.
 func (g *DirectedGraph) Edges(from string) ([]string, error) {
 	var edges []string
 
 	g.lock.RLock()
+	defer g.lock.RUnlock()
+
 	if _, ok := g.nodes[from]; !ok {
 		return edges, ErrorNodeNotFound
 	}
@@ -100,12 +256,18 @@ func (g *DirectedGraph) Edges(from string) ([]string, error) {
 			edges = append(edges, to)
 		}
 	}
-	g.lock.RUnlock()
-
 	return edges, nil
 }
 
-// Nodes returns a list of all nodes in the graph
+// NodeCount returns the number of nodes in the graph
+func (g *DirectedGraph) NodeCount() int {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return len(g.nodes)
+}
+
+// Nodes returns a list of all nodes in the graph. The list is a consistent
+// snapshot of the graph, even if it is modified concurrently.
 func (g *DirectedGraph) Nodes() []string {
 	g.lock.RLock()
 	defer g.lock.RUnlock()
@@ -181,7 +343,12 @@ func (g *DirectedGraph) topSort(seen map[string]bool, order []string, i int, key
 func (g *DirectedGraph) TopSort() []string {
 	g.lock.RLock()
 	defer g.lock.RUnlock()
+	return g.topOrder()
+}
 
+// topOrder returns a topological order of all nodes, see TopSort. The caller
+// must hold the lock.
+func (g *DirectedGraph) topOrder() []string {
 	order := make([]string, len(g.nodes))
 	i := len(order) - 1
 
@@ -195,13 +362,69 @@ func (g *DirectedGraph) TopSort() []string {
 	return order
 }
 
+// successors returns the sorted keys of all nodes that are directly connected
+// to the node identified by key. The caller must hold the lock.
+func (g *DirectedGraph) successors(key string) []string {
+	var to []string
+	for k, active := range g.edges[key] {
+		if active {
+			to = append(to, k)
+		}
+	}
+	sort.Strings(to)
+	return to
+}
+
+// dfs recursively visits nodes in a depth first way, appending each node to
+// `pre` before and to `post` after visiting its successors
+func (g *DirectedGraph) dfs(seen map[string]bool, pre, post *[]string, key string) {
+	seen[key] = true
+	*pre = append(*pre, key)
+	for _, to := range g.successors(key) {
+		if !seen[to] {
+			g.dfs(seen, pre, post, to)
+		}
+	}
+	*post = append(*post, key)
+}
+
+// DFSPreOrder returns the keys of all nodes reachable from start in depth first
+// pre-order, i.e. each node is listed before its successors. Successors are
+// visited in lexical order.
+func (g *DirectedGraph) DFSPreOrder(start string) ([]string, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if _, ok := g.nodes[start]; !ok {
+		return nil, ErrorNodeNotFound
+	}
+	var pre, post []string
+	g.dfs(make(map[string]bool), &pre, &post, start)
+	return pre, nil
+}
+
+// DFSPostOrder returns the keys of all nodes reachable from start in depth
+// first post-order, i.e. each node is listed after its successors. Successors
+// are visited in lexical order.
+func (g *DirectedGraph) DFSPostOrder(start string) ([]string, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if _, ok := g.nodes[start]; !ok {
+		return nil, ErrorNodeNotFound
+	}
+	var pre, post []string
+	g.dfs(make(map[string]bool), &pre, &post, start)
+	return post, nil
+}
+
 // String returns a human readable multi-line string describing the graph
 func (g *DirectedGraph) String() string {
 	var out bytes.Buffer
 
 	g.lock.RLock()
-	for key, value := range g.nodes {
-		out.WriteString(fmt.Sprintf("⦿ `%v` (%v)\n", key, value))
+	for key, n := range g.nodes {
+		out.WriteString(fmt.Sprintf("⦿ `%v` (%v)\n", key, n.get()))
 		for to, active := range g.edges[key] {
 			if active {
 				out.WriteString(fmt.Sprintf("⤷ `%v`\n", to))
@@ -212,3 +435,1870 @@ func (g *DirectedGraph) String() string {
 
 	return out.String()
 }
+
+// Edge classes as returned by ClassifyEdges
+const (
+	EdgeTree    = "tree"
+	EdgeBack    = "back"
+	EdgeForward = "forward"
+	EdgeCross   = "cross"
+)
+
+// sortedNodes returns the keys of all nodes in lexical order. The caller must
+// hold the lock.
+func (g *DirectedGraph) sortedNodes() []string {
+	keys := make([]string, 0, len(g.nodes))
+	for key := range g.nodes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// classifyDFS recursively visits nodes in a depth first way and records the
+// discovery and finish time of each node. Edges are classified on the go.
+func (g *DirectedGraph) classifyDFS(discovered, finished map[string]int, time *int, class map[string]map[string]string, key string) {
+	*time++
+	discovered[key] = *time
+	class[key] = make(map[string]string)
+	for _, to := range g.successors(key) {
+		switch {
+		case discovered[to] == 0:
+			class[key][to] = EdgeTree
+			g.classifyDFS(discovered, finished, time, class, to)
+		case finished[to] == 0:
+			class[key][to] = EdgeBack
+		case discovered[key] < discovered[to]:
+			class[key][to] = EdgeForward
+		default:
+			class[key][to] = EdgeCross
+		}
+	}
+	*time++
+	finished[key] = *time
+}
+
+// ClassifyEdges performs a depth first search over the whole graph and returns
+// the class (EdgeTree, EdgeBack, EdgeForward, or EdgeCross) of every edge,
+// indexed by source and destination node key. The graph is cyclic if and only
+// if there is at least one back edge.
+func (g *DirectedGraph) ClassifyEdges() map[string]map[string]string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	discovered := make(map[string]int)
+	finished := make(map[string]int)
+	class := make(map[string]map[string]string)
+	time := 0
+	for _, key := range g.sortedNodes() {
+		if discovered[key] == 0 {
+			g.classifyDFS(discovered, finished, &time, class, key)
+		}
+	}
+	return class
+}
+
+// EdgePair identifies an edge by its source and destination node keys
+type EdgePair struct {
+	From, To string
+}
+
+// FeedbackEdgeSet returns a set of edges whose removal makes the graph acyclic.
+// It uses the back edges of a depth first search, which is not necessarily the
+// smallest set possible. The set is empty for acyclic graphs.
+func (g *DirectedGraph) FeedbackEdgeSet() []EdgePair {
+	var set []EdgePair
+	class := g.ClassifyEdges()
+	for _, from := range g.Nodes() {
+		for to, c := range class[from] {
+			if c == EdgeBack {
+				set = append(set, EdgePair{From: from, To: to})
+			}
+		}
+	}
+	sort.Slice(set, func(i, j int) bool {
+		if set[i].From != set[j].From {
+			return set[i].From < set[j].From
+		}
+		return set[i].To < set[j].To
+	})
+	return set
+}
+
+// neighbors returns the undirected neighborhood of every node, ignoring edge
+// directions and self-references. The caller must hold the lock.
+func (g *DirectedGraph) neighbors() map[string]map[string]bool {
+	nb := make(map[string]map[string]bool, len(g.nodes))
+	for key := range g.nodes {
+		nb[key] = make(map[string]bool)
+	}
+	for from := range g.edges {
+		for to, active := range g.edges[from] {
+			if active && from != to {
+				nb[from][to] = true
+				nb[to][from] = true
+			}
+		}
+	}
+	return nb
+}
+
+// GreedyColoring assigns a color (starting at 0) to every node so that no two
+// nodes connected by an edge share the same color. Edge directions and
+// self-references are ignored. Nodes are colored in descending order of their
+// degree (Welsh-Powell), each receiving the smallest color not used by any of
+// its already colored neighbors.
+func (g *DirectedGraph) GreedyColoring() map[string]int {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	nb := g.neighbors()
+	order := g.sortedNodes()
+	sort.SliceStable(order, func(i, j int) bool {
+		return len(nb[order[i]]) > len(nb[order[j]])
+	})
+
+	colors := make(map[string]int, len(order))
+	for _, key := range order {
+		used := make(map[int]bool)
+		for n := range nb[key] {
+			if c, ok := colors[n]; ok {
+				used[c] = true
+			}
+		}
+		c := 0
+		for used[c] {
+			c++
+		}
+		colors[key] = c
+	}
+	return colors
+}
+
+// ChromaticNumberUpperBound returns the number of colors used by
+// GreedyColoring, which is an upper bound for the chromatic number of the graph
+func (g *DirectedGraph) ChromaticNumberUpperBound() int {
+	n := 0
+	for _, c := range g.GreedyColoring() {
+		if c+1 > n {
+			n = c + 1
+		}
+	}
+	return n
+}
+
+// weight returns the weight of the edge between from and to. The caller must
+// hold the lock.
+func (g *DirectedGraph) weight(from, to string) float64 {
+	if w, ok := g.weights[from][to]; ok {
+		return w
+	}
+	return DefaultEdgeWeight
+}
+
+// EdgeWeight returns the weight of the edge between from and to
+func (g *DirectedGraph) EdgeWeight(from, to string) (float64, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if !g.edges[from][to] {
+		return 0, ErrorEdgeNotFound
+	}
+	return g.weight(from, to), nil
+}
+
+// SetEdgeWeight assigns a weight to the existing edge between from and to
+func (g *DirectedGraph) SetEdgeWeight(from, to string, weight float64) error {
+	g.lock.Lock()
+	if !g.edges[from][to] {
+		g.lock.Unlock()
+		return ErrorEdgeNotFound
+	}
+	old := g.weight(from, to)
+	if g.weights[from] == nil {
+		g.weights[from] = make(map[string]float64)
+	}
+	g.weights[from][to] = weight
+	hook := g.onWeightChanged
+	g.lock.Unlock()
+
+	// call the hook without holding the lock so that it may use the graph
+	if hook != nil {
+		hook(from, to, old, weight)
+	}
+	return nil
+}
+
+// OnWeightChanged registers a function that is called whenever the weight of an
+// edge has been set by SetEdgeWeight. It replaces any previously registered
+// function, nil disables the hook. The function is called after the graph has
+// been unlocked.
+func (g *DirectedGraph) OnWeightChanged(f func(from, to string, oldWeight, newWeight float64)) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.onWeightChanged = f
+}
+
+// NodesImplementing returns the sorted keys of all nodes whose value is
+// assignable to typ, e.g. the keys of all nodes whose value implements an
+// interface. Nodes without a value (nil) are never included.
+func (g *DirectedGraph) NodesImplementing(typ reflect.Type) []string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	var keys []string
+	for key, n := range g.nodes {
+		if value := n.get(); value != nil && reflect.TypeOf(value).AssignableTo(typ) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// All returns an iterator over the keys and values of all nodes in undefined
+// order. The iterator works on a snapshot of the graph that is taken when the
+// iteration starts, so the graph may be modified during the iteration.
+func (g *DirectedGraph) All() iter.Seq2[string, interface{}] {
+	return func(yield func(string, interface{}) bool) {
+		g.lock.RLock()
+		keys := make([]string, 0, len(g.nodes))
+		values := make([]interface{}, 0, len(g.nodes))
+		for key, n := range g.nodes {
+			keys = append(keys, key)
+			values = append(values, n.get())
+		}
+		g.lock.RUnlock()
+
+		for i := range keys {
+			if !yield(keys[i], values[i]) {
+				return
+			}
+		}
+	}
+}
+
+// EdgesSeq returns an iterator over the keys of nodes that are directly
+// connected to the node identified by from. The sequence is empty if there is
+// no such node. The iterator works on a snapshot of the edges that is taken
+// when the iteration starts.
+func (g *DirectedGraph) EdgesSeq(from string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		g.lock.RLock()
+		to := g.successors(from)
+		g.lock.RUnlock()
+
+		for _, key := range to {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// ShortestCycleThrough returns the shortest cycle that contains the node
+// identified by key. The cycle is returned as the list of nodes along the cycle,
+// starting with key. The last node has an edge back to key. ErrorNoCycle is
+// returned if key is not part of any cycle.
+func (g *DirectedGraph) ShortestCycleThrough(key string) ([]string, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if _, ok := g.nodes[key]; !ok {
+		return nil, ErrorNodeNotFound
+	}
+
+	// breadth first search starting at key until an edge back to key is found
+	parent := map[string]string{key: key}
+	pending := []string{key}
+	for len(pending) > 0 {
+		cur := pending[0]
+		pending = pending[1:]
+		for _, to := range g.successors(cur) {
+			if to == key {
+				cycle := []string{cur}
+				for k := cur; k != key; k = parent[k] {
+					cycle = append([]string{parent[k]}, cycle...)
+				}
+				return cycle, nil
+			}
+			if _, seen := parent[to]; !seen {
+				parent[to] = cur
+				pending = append(pending, to)
+			}
+		}
+	}
+	return nil, ErrorNoCycle
+}
+
+// HasEdge returns true if there is an edge between from and to
+func (g *DirectedGraph) HasEdge(from, to string) bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.edges[from][to]
+}
+
+// HasEdges returns for each pair of node keys whether there is an edge from the
+// first to the second node
+func (g *DirectedGraph) HasEdges(pairs [][2]string) []bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	found := make([]bool, len(pairs))
+	for i, p := range pairs {
+		found[i] = g.edges[p[0]][p[1]]
+	}
+	return found
+}
+
+// tarjan holds the state of Tarjan's strongly connected components algorithm
+type tarjan struct {
+	g          *DirectedGraph
+	index      int
+	indices    map[string]int
+	lowlinks   map[string]int
+	onStack    map[string]bool
+	stack      []string
+	components [][]string
+}
+
+// strongConnect recursively assigns the node identified by key and all nodes
+// reachable from it to strongly connected components
+func (t *tarjan) strongConnect(key string) {
+	t.index++
+	t.indices[key] = t.index
+	t.lowlinks[key] = t.index
+	t.stack = append(t.stack, key)
+	t.onStack[key] = true
+
+	for _, to := range t.g.successors(key) {
+		if t.indices[to] == 0 {
+			t.strongConnect(to)
+			if t.lowlinks[to] < t.lowlinks[key] {
+				t.lowlinks[key] = t.lowlinks[to]
+			}
+		} else if t.onStack[to] && t.indices[to] < t.lowlinks[key] {
+			t.lowlinks[key] = t.indices[to]
+		}
+	}
+
+	if t.lowlinks[key] == t.indices[key] {
+		var component []string
+		for {
+			k := t.stack[len(t.stack)-1]
+			t.stack = t.stack[:len(t.stack)-1]
+			t.onStack[k] = false
+			component = append(component, k)
+			if k == key {
+				break
+			}
+		}
+		sort.Strings(component)
+		t.components = append(t.components, component)
+	}
+}
+
+// components returns the strongly connected components of the graph, each
+// sorted lexically. The caller must hold the lock.
+func (g *DirectedGraph) components() [][]string {
+	t := &tarjan{
+		g:        g,
+		indices:  make(map[string]int),
+		lowlinks: make(map[string]int),
+		onStack:  make(map[string]bool),
+	}
+	for _, key := range g.sortedNodes() {
+		if t.indices[key] == 0 {
+			t.strongConnect(key)
+		}
+	}
+	return t.components
+}
+
+// Condensation returns the condensation of the graph, in which every strongly
+// connected component is contracted to a single node. The condensation is
+// always acyclic. Each component is keyed by the lexically smallest key of its
+// members and holds the sorted keys of its members as value. The returned map
+// maps the key of each node of the graph to the key of its component.
+func (g *DirectedGraph) Condensation() (*DirectedGraph, map[string]string) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	c := New()
+	super := make(map[string]string, len(g.nodes))
+	for _, component := range g.components() {
+		c.NewNode(component[0], component)
+		for _, key := range component {
+			super[key] = component[0]
+		}
+	}
+	for from := range g.edges {
+		for to, active := range g.edges[from] {
+			if active && super[from] != super[to] {
+				c.NewEdge(super[from], super[to])
+			}
+		}
+	}
+	return c, super
+}
+
+// WeightedOutDegree returns the sum of the weights of all edges leaving the
+// node identified by key
+func (g *DirectedGraph) WeightedOutDegree(key string) (float64, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if _, ok := g.nodes[key]; !ok {
+		return 0, ErrorNodeNotFound
+	}
+	sum := 0.0
+	for to, active := range g.edges[key] {
+		if active {
+			sum += g.weight(key, to)
+		}
+	}
+	return sum, nil
+}
+
+// WeightedInDegree returns the sum of the weights of all edges pointing to the
+// node identified by key
+func (g *DirectedGraph) WeightedInDegree(key string) (float64, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if _, ok := g.nodes[key]; !ok {
+		return 0, ErrorNodeNotFound
+	}
+	sum := 0.0
+	for from := range g.edges {
+		if g.edges[from][key] {
+			sum += g.weight(from, key)
+		}
+	}
+	return sum, nil
+}
+
+// Verify checks the internal state of the graph and returns an error wrapping
+// ErrorGraphInconsistent that describes the first violation found
+func (g *DirectedGraph) Verify() error {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	for _, key := range g.sortedNodes() {
+		if g.nodes[key] == nil {
+			return fmt.Errorf("%w: node `%v` is nil", ErrorGraphInconsistent, key)
+		}
+		edges, ok := g.edges[key]
+		if !ok {
+			return fmt.Errorf("%w: node `%v` has no edge list", ErrorGraphInconsistent, key)
+		}
+		if edges == nil {
+			return fmt.Errorf("%w: node `%v` has nil edge list", ErrorGraphInconsistent, key)
+		}
+	}
+	for from := range g.edges {
+		if _, ok := g.nodes[from]; !ok {
+			return fmt.Errorf("%w: edge list of unknown node `%v`", ErrorGraphInconsistent, from)
+		}
+		for to := range g.edges[from] {
+			if _, ok := g.nodes[to]; !ok {
+				return fmt.Errorf("%w: edge `%v`->`%v` to unknown node", ErrorGraphInconsistent, from, to)
+			}
+		}
+	}
+	for from := range g.weights {
+		for to := range g.weights[from] {
+			if !g.edges[from][to] {
+				return fmt.Errorf("%w: weight of unknown edge `%v`->`%v`", ErrorGraphInconsistent, from, to)
+			}
+		}
+	}
+	return nil
+}
+
+// reachable returns the set of all nodes reachable from any of the sources,
+// including the sources themselves, using a breadth first search that follows
+// the edges returned by next. The caller must hold the lock.
+func (g *DirectedGraph) reachable(sources []string, next func(key string) []string) map[string]bool {
+	seen := make(map[string]bool)
+	var pending []string
+	for _, key := range sources {
+		if !seen[key] {
+			seen[key] = true
+			pending = append(pending, key)
+		}
+	}
+	for len(pending) > 0 {
+		cur := pending[0]
+		pending = pending[1:]
+		for _, to := range next(cur) {
+			if !seen[to] {
+				seen[to] = true
+				pending = append(pending, to)
+			}
+		}
+	}
+	return seen
+}
+
+// ReachableFromAny returns the sorted keys of all nodes that are reachable from
+// at least one of the sources, including the sources themselves
+func (g *DirectedGraph) ReachableFromAny(sources []string) ([]string, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	for _, key := range sources {
+		if _, ok := g.nodes[key]; !ok {
+			return nil, ErrorNodeNotFound
+		}
+	}
+	var keys []string
+	for key := range g.reachable(sources, g.successors) {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// TopSortFrom returns the keys of target and all nodes reachable from target,
+// ordered so that every node comes after all nodes it has an edge to. Hence, if
+// edges point from nodes to their dependencies, the dependencies of target are
+// listed in a valid build order that ends with target itself.
+// ErrorGraphIsCyclic is returned if there is a cycle reachable from target.
+func (g *DirectedGraph) TopSortFrom(target string) ([]string, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if _, ok := g.nodes[target]; !ok {
+		return nil, ErrorNodeNotFound
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var order []string
+	var visit func(key string) bool
+	visit = func(key string) bool {
+		state[key] = visiting
+		for _, to := range g.successors(key) {
+			switch state[to] {
+			case visiting:
+				return false
+			case 0:
+				if !visit(to) {
+					return false
+				}
+			}
+		}
+		state[key] = done
+		order = append(order, key)
+		return true
+	}
+	if !visit(target) {
+		return nil, ErrorGraphIsCyclic
+	}
+	return order, nil
+}
+
+// stringTree recursively renders the subtree of the node identified by key
+func (g *DirectedGraph) stringTree(out *bytes.Buffer, seen map[string]bool, prefix, key string) {
+	to := g.successors(key)
+	for i, child := range to {
+		branch, indent := "├── ", "│   "
+		if i == len(to)-1 {
+			branch, indent = "└── ", "    "
+		}
+		if seen[child] {
+			out.WriteString(fmt.Sprintf("%s%s`%v` (see above)\n", prefix, branch, child))
+			continue
+		}
+		seen[child] = true
+		out.WriteString(fmt.Sprintf("%s%s`%v` (%v)\n", prefix, branch, child, g.nodes[child].get()))
+		g.stringTree(out, seen, prefix+indent, child)
+	}
+}
+
+// StringTree returns a human readable multi-line string describing the graph as
+// a tree rooted at the node identified by root. Nodes that are reachable on
+// more than one path are expanded only once and referenced by key afterwards.
+func (g *DirectedGraph) StringTree(root string) (string, error) {
+	var out bytes.Buffer
+
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	n, ok := g.nodes[root]
+	if !ok {
+		return "", ErrorNodeNotFound
+	}
+	out.WriteString(fmt.Sprintf("`%v` (%v)\n", root, n.get()))
+	g.stringTree(&out, map[string]bool{root: true}, "", root)
+	return out.String(), nil
+}
+
+// distances returns the number of edges on the shortest path from the node
+// identified by key to every node reachable from it. The caller must hold the
+// lock.
+func (g *DirectedGraph) distances(key string) map[string]int {
+	dist := map[string]int{key: 0}
+	pending := []string{key}
+	for len(pending) > 0 {
+		cur := pending[0]
+		pending = pending[1:]
+		for to, active := range g.edges[cur] {
+			if _, seen := dist[to]; active && !seen {
+				dist[to] = dist[cur] + 1
+				pending = append(pending, to)
+			}
+		}
+	}
+	return dist
+}
+
+// eccentricity returns the greatest distance from the node identified by key to
+// any node reachable from it. The caller must hold the lock.
+func (g *DirectedGraph) eccentricity(key string) int {
+	ecc := 0
+	for _, d := range g.distances(key) {
+		if d > ecc {
+			ecc = d
+		}
+	}
+	return ecc
+}
+
+// Eccentricity returns the greatest number of edges on a shortest path from the
+// node identified by key to any other node. Nodes that are not reachable from
+// key are ignored instead of being treated as infinitely distant, so the
+// eccentricity of a node without edges is 0.
+func (g *DirectedGraph) Eccentricity(key string) (int, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if _, ok := g.nodes[key]; !ok {
+		return 0, ErrorNodeNotFound
+	}
+	return g.eccentricity(key), nil
+}
+
+// Diameter returns the greatest eccentricity of all nodes of the graph. Like
+// Eccentricity it ignores pairs of nodes that are not connected by a path.
+func (g *DirectedGraph) Diameter() int {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	diameter := 0
+	for key := range g.nodes {
+		if ecc := g.eccentricity(key); ecc > diameter {
+			diameter = ecc
+		}
+	}
+	return diameter
+}
+
+// MaxIsomorphismSize is the maximum number of nodes of graphs that IsIsomorphic
+// compares. The backtracking search used takes exponential time in the worst
+// case.
+const MaxIsomorphismSize = 12
+
+// adjacencyMatrix returns the sorted keys of all nodes and a matrix that holds
+// whether there is an edge between the nodes at the respective indices
+func (g *DirectedGraph) adjacencyMatrix() ([]string, [][]bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	keys := g.sortedNodes()
+	index := make(map[string]int, len(keys))
+	for i, key := range keys {
+		index[key] = i
+	}
+	matrix := make([][]bool, len(keys))
+	for i, key := range keys {
+		matrix[i] = make([]bool, len(keys))
+		for to, active := range g.edges[key] {
+			if active {
+				matrix[i][index[to]] = true
+			}
+		}
+	}
+	return keys, matrix
+}
+
+// degrees returns the in- and out-degree of every node of an adjacency matrix
+func degrees(matrix [][]bool) [][2]int {
+	deg := make([][2]int, len(matrix))
+	for i := range matrix {
+		for j := range matrix[i] {
+			if matrix[i][j] {
+				deg[i][1]++
+				deg[j][0]++
+			}
+		}
+	}
+	return deg
+}
+
+// IsIsomorphic returns true if the graph and other have the same structure,
+// i.e. if there is a mapping between their nodes that preserves all edges.
+// Values are not compared. ErrorGraphTooLarge is returned if the graphs have
+// more than MaxIsomorphismSize nodes.
+func (g *DirectedGraph) IsIsomorphic(other *DirectedGraph) (bool, error) {
+	// take snapshots one after another so that the graphs are never locked at
+	// the same time
+	_, a := g.adjacencyMatrix()
+	_, b := other.adjacencyMatrix()
+
+	if len(a) != len(b) {
+		return false, nil
+	}
+	if len(a) > MaxIsomorphismSize {
+		return false, ErrorGraphTooLarge
+	}
+	degA, degB := degrees(a), degrees(b)
+	sortedA := append([][2]int(nil), degA...)
+	sortedB := append([][2]int(nil), degB...)
+	for _, deg := range [][][2]int{sortedA, sortedB} {
+		sort.Slice(deg, func(i, j int) bool {
+			if deg[i][0] != deg[j][0] {
+				return deg[i][0] < deg[j][0]
+			}
+			return deg[i][1] < deg[j][1]
+		})
+	}
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false, nil
+		}
+	}
+
+	// backtracking search for a mapping of nodes of a to nodes of b
+	mapping := make([]int, len(a))
+	used := make([]bool, len(b))
+	var match func(i int) bool
+	match = func(i int) bool {
+		if i == len(a) {
+			return true
+		}
+		for j := range b {
+			if used[j] || degA[i] != degB[j] || a[i][i] != b[j][j] {
+				continue
+			}
+			consistent := true
+			for k := 0; k < i && consistent; k++ {
+				m := mapping[k]
+				consistent = a[i][k] == b[j][m] && a[k][i] == b[m][j]
+			}
+			if !consistent {
+				continue
+			}
+			mapping[i] = j
+			used[j] = true
+			if match(i + 1) {
+				return true
+			}
+			used[j] = false
+		}
+		return false
+	}
+	return match(0), nil
+}
+
+// SortedEdges returns all edges of the graph as pairs of source and destination
+// node keys, sorted by source and then by destination
+func (g *DirectedGraph) SortedEdges() [][2]string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	var edges [][2]string
+	for _, from := range g.sortedNodes() {
+		for _, to := range g.successors(from) {
+			edges = append(edges, [2]string{from, to})
+		}
+	}
+	return edges
+}
+
+// PathWeight returns the sum of the weights of all edges along path, which is
+// given as a list of node keys. If two consecutive nodes of the path are not
+// connected by an edge, an error wrapping ErrorEdgeNotFound is returned.
+func (g *DirectedGraph) PathWeight(path []string) (float64, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	for _, key := range path {
+		if _, ok := g.nodes[key]; !ok {
+			return 0, fmt.Errorf("%w: `%v`", ErrorNodeNotFound, key)
+		}
+	}
+	sum := 0.0
+	for i := 1; i < len(path); i++ {
+		from, to := path[i-1], path[i]
+		if !g.edges[from][to] {
+			return 0, fmt.Errorf("%w: `%v`->`%v`", ErrorEdgeNotFound, from, to)
+		}
+		sum += g.weight(from, to)
+	}
+	return sum, nil
+}
+
+// cyclic returns true if the graph contains a cycle. Unlike isCyclicDFS it
+// visits every node only once. The caller must hold the lock.
+func (g *DirectedGraph) cyclic() bool {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(g.nodes))
+	var visit func(key string) bool
+	visit = func(key string) bool {
+		state[key] = visiting
+		for to, active := range g.edges[key] {
+			if !active {
+				continue
+			}
+			if state[to] == visiting || (state[to] == 0 && visit(to)) {
+				return true
+			}
+		}
+		state[key] = done
+		return false
+	}
+	for key := range g.nodes {
+		if state[key] == 0 && visit(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// inDegrees returns the number of edges pointing to each node. The caller must
+// hold the lock.
+func (g *DirectedGraph) inDegrees() map[string]int {
+	in := make(map[string]int, len(g.nodes))
+	for key := range g.nodes {
+		in[key] = 0
+	}
+	for from := range g.edges {
+		for to, active := range g.edges[from] {
+			if active {
+				in[to]++
+			}
+		}
+	}
+	return in
+}
+
+// SourceSinkPaths returns all paths that start at a node without incoming
+// edges (source) and end at a node without outgoing edges (sink). A node
+// without any edges forms a path on its own. If limit is greater than zero, at
+// most limit paths are returned. ErrorGraphIsCyclic is returned for cyclic
+// graphs.
+func (g *DirectedGraph) SourceSinkPaths(limit int) ([][]string, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if g.cyclic() {
+		return nil, ErrorGraphIsCyclic
+	}
+
+	var paths [][]string
+	full := func() bool {
+		return limit > 0 && len(paths) >= limit
+	}
+	var walk func(path []string)
+	walk = func(path []string) {
+		to := g.successors(path[len(path)-1])
+		if len(to) == 0 {
+			paths = append(paths, append([]string(nil), path...))
+			return
+		}
+		for _, key := range to {
+			if full() {
+				return
+			}
+			walk(append(path, key))
+		}
+	}
+
+	in := g.inDegrees()
+	for _, key := range g.sortedNodes() {
+		if full() {
+			break
+		}
+		if in[key] == 0 {
+			walk([]string{key})
+		}
+	}
+	return paths, nil
+}
+
+// snapshot holds a copy of the nodes, active edges, and weights of a graph
+type snapshot struct {
+	values  map[string]interface{}
+	edges   map[string]map[string]bool
+	weights map[string]map[string]float64
+}
+
+// snapshot returns a copy of the graph that can be used without holding the
+// lock
+func (g *DirectedGraph) snapshot() snapshot {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	s := snapshot{
+		values:  make(map[string]interface{}, len(g.nodes)),
+		edges:   make(map[string]map[string]bool, len(g.nodes)),
+		weights: make(map[string]map[string]float64),
+	}
+	for key, n := range g.nodes {
+		s.values[key] = n.get()
+		s.edges[key] = make(map[string]bool)
+		for to, active := range g.edges[key] {
+			if active {
+				s.edges[key][to] = true
+			}
+		}
+	}
+	for from := range g.weights {
+		s.weights[from] = make(map[string]float64)
+		for to, w := range g.weights[from] {
+			s.weights[from][to] = w
+		}
+	}
+	return s
+}
+
+// Union returns a new graph holding all nodes and edges of the graph and other.
+// If both graphs hold a node with the same key but values that are not deeply
+// equal, the value of the new node is determined by calling resolve with the
+// key and both values. ErrorValueConflict is returned if resolve is nil. Edge
+// weights assigned in the graph take precedence over those assigned in other.
+func (g *DirectedGraph) Union(other *DirectedGraph, resolve func(key string, a, b interface{}) interface{}) (*DirectedGraph, error) {
+	// take snapshots one after another so that the graphs are never locked at
+	// the same time
+	a, b := g.snapshot(), other.snapshot()
+
+	u := New()
+	for key, value := range a.values {
+		u.nodes[key] = &node{value: value}
+		u.edges[key] = make(map[string]bool)
+	}
+	for key, value := range b.values {
+		if n, ok := u.nodes[key]; ok {
+			if !reflect.DeepEqual(n.value, value) {
+				if resolve == nil {
+					return nil, fmt.Errorf("%w: `%v`", ErrorValueConflict, key)
+				}
+				n.value = resolve(key, n.value, value)
+			}
+			continue
+		}
+		u.nodes[key] = &node{value: value}
+		u.edges[key] = make(map[string]bool)
+	}
+	for _, s := range []snapshot{b, a} {
+		for from := range s.edges {
+			for to := range s.edges[from] {
+				u.edges[from][to] = true
+			}
+		}
+		for from := range s.weights {
+			if u.weights[from] == nil {
+				u.weights[from] = make(map[string]float64)
+			}
+			for to, w := range s.weights[from] {
+				u.weights[from][to] = w
+			}
+		}
+	}
+	return u, nil
+}
+
+// Intersection returns a new graph holding only the nodes and edges that exist
+// in both the graph and other. Values and edge weights are taken from the
+// graph.
+func (g *DirectedGraph) Intersection(other *DirectedGraph) *DirectedGraph {
+	a, b := g.snapshot(), other.snapshot()
+
+	n := New()
+	for key, value := range a.values {
+		if _, ok := b.values[key]; ok {
+			n.nodes[key] = &node{value: value}
+			n.edges[key] = make(map[string]bool)
+		}
+	}
+	for from := range n.edges {
+		for to := range a.edges[from] {
+			if b.edges[from][to] {
+				n.edges[from][to] = true
+				if w, ok := a.weights[from][to]; ok {
+					if n.weights[from] == nil {
+						n.weights[from] = make(map[string]float64)
+					}
+					n.weights[from][to] = w
+				}
+			}
+		}
+	}
+	return n
+}
+
+// reverse returns the keys of all nodes that have an edge to each node, i.e.
+// the adjacency of the transposed graph. The caller must hold the lock.
+func (g *DirectedGraph) reverse() map[string][]string {
+	rev := make(map[string][]string, len(g.nodes))
+	for _, from := range g.sortedNodes() {
+		for _, to := range g.successors(from) {
+			rev[to] = append(rev[to], from)
+		}
+	}
+	return rev
+}
+
+// NodesNotReachingSinks returns the sorted keys of all nodes from which no node
+// without outgoing edges (sink) can be reached
+func (g *DirectedGraph) NodesNotReachingSinks() []string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	var sinks []string
+	for _, key := range g.sortedNodes() {
+		if len(g.successors(key)) == 0 {
+			sinks = append(sinks, key)
+		}
+	}
+	rev := g.reverse()
+	reaching := g.reachable(sinks, func(key string) []string { return rev[key] })
+
+	var keys []string
+	for _, key := range g.sortedNodes() {
+		if !reaching[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// FindNode returns the key of a node for which pred returns true and whether
+// such a node has been found. If there are several matching nodes, it is
+// undefined which one is returned. The nodes are copied before pred is called
+// for the first time, so pred may use the graph.
+func (g *DirectedGraph) FindNode(pred func(key string, value interface{}) bool) (string, bool) {
+	for key, value := range g.All() {
+		if pred(key, value) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// FindNodes returns the sorted keys of all nodes for which pred returns true.
+// The nodes are copied before pred is called for the first time, so pred may
+// use the graph.
+func (g *DirectedGraph) FindNodes(pred func(key string, value interface{}) bool) []string {
+	var keys []string
+	for key, value := range g.All() {
+		if pred(key, value) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WalkDepth visits start and all nodes reachable from it in breadth first order,
+// calling visit with the key of each node and its distance from start. Nodes
+// more than maxDepth edges away from start are not visited. The walk stops at
+// the first error returned by visit, which is then returned. The nodes to visit
+// are determined before visit is called for the first time, so visit may use
+// the graph.
+func (g *DirectedGraph) WalkDepth(start string, maxDepth int, visit func(key string, depth int) error) error {
+	g.lock.RLock()
+	if _, ok := g.nodes[start]; !ok {
+		g.lock.RUnlock()
+		return ErrorNodeNotFound
+	}
+	depth := map[string]int{start: 0}
+	order := []string{start}
+	for i := 0; i < len(order); i++ {
+		cur := order[i]
+		if depth[cur] >= maxDepth {
+			continue
+		}
+		for _, to := range g.successors(cur) {
+			if _, seen := depth[to]; !seen {
+				depth[to] = depth[cur] + 1
+				order = append(order, to)
+			}
+		}
+	}
+	g.lock.RUnlock()
+
+	for _, key := range order {
+		if err := visit(key, depth[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ToDOTStructureOnly returns a description of the graph in the DOT language
+// that contains the keys of all nodes and all edges, but none of the values
+func (g *DirectedGraph) ToDOTStructureOnly() string {
+	var out bytes.Buffer
+
+	g.lock.RLock()
+	out.WriteString("digraph {\n")
+	for _, key := range g.sortedNodes() {
+		out.WriteString(fmt.Sprintf("  %s;\n", strconv.Quote(key)))
+	}
+	for _, from := range g.sortedNodes() {
+		for _, to := range g.successors(from) {
+			out.WriteString(fmt.Sprintf("  %s -> %s;\n", strconv.Quote(from), strconv.Quote(to)))
+		}
+	}
+	out.WriteString("}\n")
+	g.lock.RUnlock()
+
+	return out.String()
+}
+
+// levels returns the nodes of the graph grouped in topological levels. The
+// first level holds all nodes without incoming edges, every further level holds
+// the nodes whose predecessors are all on previous levels. Each level is sorted
+// lexically. The caller must hold the lock.
+func (g *DirectedGraph) levels() ([][]string, error) {
+	in := g.inDegrees()
+	var level []string
+	for _, key := range g.sortedNodes() {
+		if in[key] == 0 {
+			level = append(level, key)
+		}
+	}
+
+	var levels [][]string
+	n := 0
+	for len(level) > 0 {
+		levels = append(levels, level)
+		n += len(level)
+		var next []string
+		for _, from := range level {
+			for _, to := range g.successors(from) {
+				in[to]--
+				if in[to] == 0 {
+					next = append(next, to)
+				}
+			}
+		}
+		sort.Strings(next)
+		level = next
+	}
+	if n != len(g.nodes) {
+		return nil, ErrorGraphIsCyclic
+	}
+	return levels, nil
+}
+
+// TopSortLevels returns the keys of all nodes grouped in topological levels.
+// The first level holds all nodes without incoming edges, every further level
+// holds the nodes whose predecessors are all on previous levels. Nodes on the
+// same level do not depend on each other. ErrorGraphIsCyclic is returned for
+// cyclic graphs.
+func (g *DirectedGraph) TopSortLevels() ([][]string, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.levels()
+}
+
+// MaxWidth returns the number of nodes on the largest level returned by
+// TopSortLevels, i.e. the maximum number of nodes that can be processed in
+// parallel
+func (g *DirectedGraph) MaxWidth() (int, error) {
+	levels, err := g.TopSortLevels()
+	if err != nil {
+		return 0, err
+	}
+	width := 0
+	for _, level := range levels {
+		if len(level) > width {
+			width = len(level)
+		}
+	}
+	return width, nil
+}
+
+// BetweennessCentrality returns the betweenness centrality of every node, i.e.
+// the sum over all pairs of other nodes of the fraction of shortest paths
+// between them that pass through the node. Edge weights are ignored and the
+// values are not normalized. It uses the algorithm by Brandes.
+func (g *DirectedGraph) BetweennessCentrality() map[string]float64 {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	cb := make(map[string]float64, len(g.nodes))
+	for key := range g.nodes {
+		cb[key] = 0
+	}
+	for s := range g.nodes {
+		// breadth first search counting shortest paths from s
+		var stack []string
+		pred := make(map[string][]string)
+		sigma := map[string]float64{s: 1}
+		dist := map[string]int{s: 0}
+		pending := []string{s}
+		for len(pending) > 0 {
+			v := pending[0]
+			pending = pending[1:]
+			stack = append(stack, v)
+			for w, active := range g.edges[v] {
+				if !active {
+					continue
+				}
+				if _, seen := dist[w]; !seen {
+					dist[w] = dist[v] + 1
+					pending = append(pending, w)
+				}
+				if dist[w] == dist[v]+1 {
+					sigma[w] += sigma[v]
+					pred[w] = append(pred[w], v)
+				}
+			}
+		}
+
+		// accumulate dependencies in order of non-increasing distance from s
+		delta := make(map[string]float64)
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, v := range pred[w] {
+				delta[v] += sigma[v] / sigma[w] * (1 + delta[w])
+			}
+			if w != s {
+				cb[w] += delta[w]
+			}
+		}
+	}
+	return cb
+}
+
+// mermaidEscape replaces characters that break Mermaid labels with entity codes
+func mermaidEscape(s string) string {
+	var out bytes.Buffer
+	for _, r := range s {
+		switch r {
+		case '"', '#', '[', ']', '(', ')', '{', '}', '<', '>', '|':
+			out.WriteString(fmt.Sprintf("#%d;", r))
+		case '\n':
+			out.WriteString("<br>")
+		default:
+			out.WriteRune(r)
+		}
+	}
+	return out.String()
+}
+
+// ToMermaid returns a description of the graph as a Mermaid flowchart. Nodes
+// are labeled with their values. Since keys may contain characters that are not
+// allowed in Mermaid identifiers, nodes are identified as n0, n1, and so on, in
+// lexical order of their keys.
+func (g *DirectedGraph) ToMermaid() string {
+	var out bytes.Buffer
+
+	g.lock.RLock()
+	keys := g.sortedNodes()
+	id := make(map[string]string, len(keys))
+	out.WriteString("graph TD\n")
+	for i, key := range keys {
+		id[key] = fmt.Sprintf("n%d", i)
+		label := fmt.Sprintf("%v", g.nodes[key].get())
+		out.WriteString(fmt.Sprintf("    %s[\"%s\"]\n", id[key], mermaidEscape(label)))
+	}
+	for _, from := range keys {
+		for _, to := range g.successors(from) {
+			out.WriteString(fmt.Sprintf("    %s --> %s\n", id[from], id[to]))
+		}
+	}
+	g.lock.RUnlock()
+
+	return out.String()
+}
+
+// ArticulationPoints returns the sorted keys of all nodes whose removal would
+// split the graph into more connected components, with edge directions ignored.
+// Each connected component is analyzed separately.
+func (g *DirectedGraph) ArticulationPoints() []string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	nb := g.neighbors()
+	disc := make(map[string]int)
+	low := make(map[string]int)
+	points := make(map[string]bool)
+	time := 0
+
+	var visit func(key, parent string, root bool)
+	visit = func(key, parent string, root bool) {
+		time++
+		disc[key] = time
+		low[key] = time
+		children := 0
+		for n := range nb[key] {
+			if disc[n] == 0 {
+				children++
+				visit(n, key, false)
+				if low[n] < low[key] {
+					low[key] = low[n]
+				}
+				if !root && low[n] >= disc[key] {
+					points[key] = true
+				}
+			} else if (root || n != parent) && disc[n] < low[key] {
+				low[key] = disc[n]
+			}
+		}
+		if root && children > 1 {
+			points[key] = true
+		}
+	}
+	for _, key := range g.sortedNodes() {
+		if disc[key] == 0 {
+			visit(key, "", true)
+		}
+	}
+
+	var keys []string
+	for key := range points {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Triples returns the graph as a list of subject-predicate-object triples.
+// Every edge becomes a triple {from, edgePredicate, to}. If valuePredicate is
+// not empty, every node holding a value other than nil additionally becomes a
+// triple {key, valuePredicate, value} with the value in its default format.
+// Triples are sorted by subject, value triples come first.
+func (g *DirectedGraph) Triples(edgePredicate, valuePredicate string) [][3]string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	var triples [][3]string
+	for _, key := range g.sortedNodes() {
+		if value := g.nodes[key].get(); valuePredicate != "" && value != nil {
+			triples = append(triples, [3]string{key, valuePredicate, fmt.Sprintf("%v", value)})
+		}
+		for _, to := range g.successors(key) {
+			triples = append(triples, [3]string{key, edgePredicate, to})
+		}
+	}
+	return triples
+}
+
+// MinimalRootSet returns the smallest set of nodes from which every node of
+// the graph is reachable. For an acyclic graph these are exactly the nodes
+// without incoming edges. For a cyclic graph a strongly connected component
+// without incoming edges from other components may consist of several nodes,
+// any of which reaches all others; only the lexically smallest key of such a
+// component is returned. The keys are sorted. The error is always nil, it is
+// part of the signature to allow for validation in the future.
+func (g *DirectedGraph) MinimalRootSet() ([]string, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	components := g.components()
+	super := make(map[string]string, len(g.nodes))
+	for _, component := range components {
+		for _, key := range component {
+			super[key] = component[0]
+		}
+	}
+	entered := make(map[string]bool, len(components))
+	for from := range g.edges {
+		for to, active := range g.edges[from] {
+			if active && super[from] != super[to] {
+				entered[super[to]] = true
+			}
+		}
+	}
+
+	roots := []string{}
+	for _, component := range components {
+		if !entered[component[0]] {
+			roots = append(roots, component[0])
+		}
+	}
+	sort.Strings(roots)
+	return roots, nil
+}
+
+// IsStronglyConnected returns true if every node of the graph can reach every
+// other node. Empty graphs and graphs with a single node are strongly
+// connected.
+func (g *DirectedGraph) IsStronglyConnected() bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if len(g.nodes) <= 1 {
+		return true
+	}
+	var start string
+	for key := range g.nodes {
+		start = key
+		break
+	}
+	// every node must be reachable from start and start must be reachable
+	// from every node, i.e. every node must be reachable in the transpose
+	if len(g.reachable([]string{start}, g.successors)) != len(g.nodes) {
+		return false
+	}
+	rev := g.reverse()
+	predecessors := func(key string) []string {
+		return rev[key]
+	}
+	return len(g.reachable([]string{start}, predecessors)) == len(g.nodes)
+}
+
+// TopSortToQueue returns a queue holding all nodes in topological order, with
+// the first node to process at the front. ErrorGraphIsCyclic is returned for
+// cyclic graphs.
+func (g *DirectedGraph) TopSortToQueue() (*queue.Queue, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if g.cyclic() {
+		return nil, ErrorGraphIsCyclic
+	}
+	q := &queue.Queue{}
+	for _, key := range g.topOrder() {
+		q.Add(key)
+	}
+	return q, nil
+}
+
+// Normalize removes stale entries from the adjacency of the graph: entries for
+// edges that are not active, entries pointing to unknown nodes, and weights of
+// edges that do not exist. If stripSelfLoops is true, edges from a node to
+// itself are removed as well. Normalize returns the number of adjacency entries
+// removed, not counting weights. The edges of the graph are not otherwise
+// changed.
+func (g *DirectedGraph) Normalize(stripSelfLoops bool) int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	removed := 0
+	for from, tos := range g.edges {
+		for to, active := range tos {
+			_, known := g.nodes[to]
+			if !active || !known || (stripSelfLoops && from == to) {
+				delete(tos, to)
+				removed++
+			}
+		}
+	}
+	for from, tos := range g.weights {
+		for to := range tos {
+			if !g.edges[from][to] {
+				delete(tos, to)
+			}
+		}
+		if len(tos) == 0 {
+			delete(g.weights, from)
+		}
+	}
+	return removed
+}
+
+// KeysWithValue returns the sorted keys of all nodes whose value is deeply equal
+// to value
+func (g *DirectedGraph) KeysWithValue(value interface{}) []string {
+	return g.FindNodes(func(_ string, v interface{}) bool {
+		return reflect.DeepEqual(value, v)
+	})
+}
+
+// Density returns the fraction of possible edges between distinct nodes that
+// are present in the graph, i.e. the number of edges divided by n*(n-1) for n
+// nodes. Self-loops are not counted. Graphs with less than two nodes have a
+// density of 0.
+func (g *DirectedGraph) Density() float64 {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	n := len(g.nodes)
+	if n < 2 {
+		return 0
+	}
+	count := 0
+	for from := range g.edges {
+		for to, active := range g.edges[from] {
+			if active && from != to {
+				count++
+			}
+		}
+	}
+	return float64(count) / float64(n*(n-1))
+}
+
+// ShortestPathFunc returns a path with the fewest edges from the node
+// identified by from to the node identified by to, including both. Only edges
+// for which allowed returns true are followed. ErrorNoPath is returned if there
+// is no such path. The graph is locked while allowed is called, so allowed must
+// not use the graph.
+func (g *DirectedGraph) ShortestPathFunc(from, to string, allowed func(from, to string) bool) ([]string, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if _, ok := g.nodes[from]; !ok {
+		return nil, ErrorNodeNotFound
+	}
+	if _, ok := g.nodes[to]; !ok {
+		return nil, ErrorNodeNotFound
+	}
+
+	parent := map[string]string{from: from}
+	pending := []string{from}
+	for len(pending) > 0 {
+		cur := pending[0]
+		pending = pending[1:]
+		if cur == to {
+			path := []string{to}
+			for k := to; k != from; k = parent[k] {
+				path = append([]string{parent[k]}, path...)
+			}
+			return path, nil
+		}
+		for _, next := range g.successors(cur) {
+			if _, seen := parent[next]; !seen && allowed(cur, next) {
+				parent[next] = cur
+				pending = append(pending, next)
+			}
+		}
+	}
+	return nil, ErrorNoPath
+}
+
+// ImportEdges adds an edge for each pair of node keys, creating nodes that do
+// not exist yet with the default value (see SetDefaultValue), and returns the
+// number of edges that did not exist before. Unless allowCycles is true, the
+// graph is validated afterwards: if it is cyclic, the sorted keys of all nodes
+// that are part of a cycle are returned together with ErrorGraphIsCyclic. The
+// edges are added regardless of the outcome of the validation.
+func (g *DirectedGraph) ImportEdges(pairs [][2]string, allowCycles bool) (created int, cyclicNodes []string, err error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	for _, p := range pairs {
+		from, to := p[0], p[1]
+		g.ensureNode(from)
+		g.ensureNode(to)
+		if g.edges[from][to] {
+			g.redundantEdges++
+		} else {
+			created++
+		}
+		g.edges[from][to] = true
+	}
+	if allowCycles {
+		return created, nil, nil
+	}
+
+	for _, component := range g.components() {
+		if len(component) > 1 || g.edges[component[0]][component[0]] {
+			cyclicNodes = append(cyclicNodes, component...)
+		}
+	}
+	if len(cyclicNodes) > 0 {
+		sort.Strings(cyclicNodes)
+		return created, cyclicNodes, ErrorGraphIsCyclic
+	}
+	return created, nil, nil
+}
+
+// SCCOf returns the sorted keys of all nodes in the strongly connected
+// component of the node identified by key, including the node itself. These
+// are the nodes that are both reachable from the node and able to reach it.
+func (g *DirectedGraph) SCCOf(key string) ([]string, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if _, ok := g.nodes[key]; !ok {
+		return nil, ErrorNodeNotFound
+	}
+	forward := g.reachable([]string{key}, g.successors)
+	rev := g.reverse()
+	backward := g.reachable([]string{key}, func(k string) []string {
+		return rev[k]
+	})
+
+	var keys []string
+	for k := range forward {
+		if backward[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// ReverseInPlace reverses the direction of all edges of the graph. Edge weights
+// stay with their edges.
+func (g *DirectedGraph) ReverseInPlace() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	edges := make(map[string]map[string]bool, len(g.nodes))
+	for key := range g.nodes {
+		edges[key] = make(map[string]bool)
+	}
+	for from, tos := range g.edges {
+		for to, active := range tos {
+			if active {
+				edges[to][from] = true
+			}
+		}
+	}
+	weights := make(map[string]map[string]float64)
+	for from, tos := range g.weights {
+		for to, w := range tos {
+			if weights[to] == nil {
+				weights[to] = make(map[string]float64)
+			}
+			weights[to][from] = w
+		}
+	}
+	g.edges = edges
+	g.weights = weights
+}
+
+// IsCutVertexFrom returns true if removing the node identified by candidate
+// would make some other node unreachable from the node identified by root that
+// is reachable now. If candidate is root itself, this is the case if root
+// reaches any other node.
+func (g *DirectedGraph) IsCutVertexFrom(root, candidate string) (bool, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if _, ok := g.nodes[root]; !ok {
+		return false, ErrorNodeNotFound
+	}
+	if _, ok := g.nodes[candidate]; !ok {
+		return false, ErrorNodeNotFound
+	}
+
+	before := g.reachable([]string{root}, g.successors)
+	if !before[candidate] {
+		return false, nil
+	}
+	if root == candidate {
+		return len(before) > 1, nil
+	}
+	after := g.reachable([]string{root}, func(key string) []string {
+		if key == candidate {
+			return nil
+		}
+		return g.successors(key)
+	})
+	// after still contains candidate, which is reached but not left
+	return len(after) < len(before), nil
+}
+
+// InDegreeHistogram returns a map from each in-degree to the number of nodes
+// with that many incoming edges
+func (g *DirectedGraph) InDegreeHistogram() map[int]int {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	histogram := make(map[int]int)
+	for _, degree := range g.inDegrees() {
+		histogram[degree]++
+	}
+	return histogram
+}
+
+// OutDegreeHistogram returns a map from each out-degree to the number of nodes
+// with that many outgoing edges
+func (g *DirectedGraph) OutDegreeHistogram() map[int]int {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	histogram := make(map[int]int)
+	for key := range g.nodes {
+		degree := 0
+		for _, active := range g.edges[key] {
+			if active {
+				degree++
+			}
+		}
+		histogram[degree]++
+	}
+	return histogram
+}
+
+// TopSortStream emits the same levels as TopSortLevels one level at a time on
+// the first returned channel. The levels are computed from a snapshot of the
+// graph taken when TopSortStream is called, but each level is only computed
+// once the previous one has been received. When all levels have been emitted,
+// both channels are closed. ErrorGraphIsCyclic is sent on the second channel
+// for cyclic graphs before any level is emitted, and the context's error is
+// sent on it if the context is done before all levels have been received.
+func (g *DirectedGraph) TopSortStream(ctx context.Context) (<-chan []string, <-chan error) {
+	levels := make(chan []string)
+	errs := make(chan error, 1)
+
+	g.lock.RLock()
+	if g.cyclic() {
+		g.lock.RUnlock()
+		errs <- ErrorGraphIsCyclic
+		close(levels)
+		close(errs)
+		return levels, errs
+	}
+	in := g.inDegrees()
+	succ := make(map[string][]string, len(g.nodes))
+	var level []string
+	for _, key := range g.sortedNodes() {
+		succ[key] = g.successors(key)
+		if in[key] == 0 {
+			level = append(level, key)
+		}
+	}
+	g.lock.RUnlock()
+
+	go func() {
+		defer close(errs)
+		defer close(levels)
+		for len(level) > 0 {
+			select {
+			case levels <- level:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+			var next []string
+			for _, from := range level {
+				for _, to := range succ[from] {
+					in[to]--
+					if in[to] == 0 {
+						next = append(next, to)
+					}
+				}
+			}
+			sort.Strings(next)
+			level = next
+		}
+	}()
+	return levels, errs
+}
+
+// AdjacencyCopy returns a map from the key of each node to the sorted keys of
+// the nodes it is directly connected to. Nodes without outgoing edges map to an
+// empty slice. The map is a copy taken under a single lock, so it is a
+// consistent snapshot that can be modified freely without affecting the graph.
+func (g *DirectedGraph) AdjacencyCopy() map[string][]string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	adjacency := make(map[string][]string, len(g.nodes))
+	for key := range g.nodes {
+		to := g.successors(key)
+		if to == nil {
+			to = []string{}
+		}
+		adjacency[key] = to
+	}
+	return adjacency
+}
+
+// AddTag assigns a tag to the node identified by key. Assigning a tag the node
+// already has is a no-op.
+func (g *DirectedGraph) AddTag(key, tag string) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if _, ok := g.nodes[key]; !ok {
+		return ErrorNodeNotFound
+	}
+	if g.tags[key] == nil {
+		g.tags[key] = make(map[string]bool)
+	}
+	g.tags[key][tag] = true
+	return nil
+}
+
+// RemoveTag removes a tag from the node identified by key. Removing a tag the
+// node does not have is a no-op.
+func (g *DirectedGraph) RemoveTag(key, tag string) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if _, ok := g.nodes[key]; !ok {
+		return ErrorNodeNotFound
+	}
+	delete(g.tags[key], tag)
+	if len(g.tags[key]) == 0 {
+		delete(g.tags, key)
+	}
+	return nil
+}
+
+// NodesWithTag returns the sorted keys of all nodes that have the tag
+func (g *DirectedGraph) NodesWithTag(tag string) []string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	var keys []string
+	for key, tags := range g.tags {
+		if tags[tag] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ConnectedClosure returns the sorted keys of all nodes that are reachable from
+// or able to reach any of the nodes identified by keys, including these nodes
+// themselves
+func (g *DirectedGraph) ConnectedClosure(keys []string) ([]string, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	for _, key := range keys {
+		if _, ok := g.nodes[key]; !ok {
+			return nil, ErrorNodeNotFound
+		}
+	}
+	closure := g.reachable(keys, g.successors)
+	rev := g.reverse()
+	for key := range g.reachable(keys, func(k string) []string { return rev[k] }) {
+		closure[key] = true
+	}
+
+	var result []string
+	for key := range closure {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// IsSpanningTree returns true if the edges of the graph form a tree rooted at
+// the node identified by root that covers all nodes: root has no incoming
+// edges, every other node has exactly one, and every node is reachable from
+// root.
+func (g *DirectedGraph) IsSpanningTree(root string) (bool, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if _, ok := g.nodes[root]; !ok {
+		return false, ErrorNodeNotFound
+	}
+	for key, degree := range g.inDegrees() {
+		if (key == root && degree != 0) || (key != root && degree != 1) {
+			return false, nil
+		}
+	}
+	return len(g.reachable([]string{root}, g.successors)) == len(g.nodes), nil
+}
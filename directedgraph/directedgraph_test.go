@@ -1,6 +1,11 @@
 package directedgraph
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
 	"testing"
 )
 
@@ -79,10 +84,10 @@ func TestGraphNewNode(t *testing.T) {
 			g.NewNode(nd.key, nd.value)
 		}
 		for _, nd := range nodes {
-			if value, ok := g.nodes[nd.key]; !ok {
+			if n, ok := g.nodes[nd.key]; !ok {
 				t.Errorf("expected node `%v` not found in node list", nd.key)
-			} else if value != nd.value {
-				t.Errorf("expected node value `%v`, got `%v`", nd.value, value)
+			} else if n.value != nd.value {
+				t.Errorf("expected node value `%v`, got `%v`", nd.value, n.value)
 			}
 		}
 	})
@@ -113,6 +118,28 @@ func TestGraphValue(t *testing.T) {
 	})
 }
 
+func TestGraphValues(t *testing.T) {
+	g := New()
+	for _, nd := range nodes {
+		g.NewNode(nd.key, nd.value)
+	}
+	t.Run("retrieve values", func(t *testing.T) {
+		values, err := g.Values([]string{"foo", "scary"})
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if len(values) != 2 || values["foo"] != "bar" || values["scary"] != 1337 {
+			t.Errorf("unexpected values `%v`", values)
+		}
+	})
+	t.Run("accessing unknown node", func(t *testing.T) {
+		_, err := g.Values([]string{"foo", "unknown"})
+		if !errors.Is(err, ErrorNodeNotFound) {
+			t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+		}
+	})
+}
+
 func TestGraphUpdateValue(t *testing.T) {
 	t.Run("update value of existing node", func(t *testing.T) {
 		g := New()
@@ -122,7 +149,7 @@ func TestGraphUpdateValue(t *testing.T) {
 			if err != nil {
 				t.Errorf("node `%v`: %v", nd.key, err)
 			}
-			if value := g.nodes["foo"]; value != nd.value {
+			if value := g.nodes["foo"].value; value != nd.value {
 				t.Errorf("expected node value `%v`, got `%v`", nd.value, value)
 			}
 		}
@@ -136,6 +163,90 @@ func TestGraphUpdateValue(t *testing.T) {
 	})
 }
 
+func TestGraphUpdateValueCAS(t *testing.T) {
+	t.Run("compare and swap", func(t *testing.T) {
+		g := New()
+		g.NewNode("foo", []string{"a"})
+		ok, err := g.UpdateValueCAS("foo", []string{"b"}, []string{"c"})
+		if ok || err != nil {
+			t.Errorf("expected `false` and `nil` got `%v` and `%v`", ok, err)
+		}
+		ok, err = g.UpdateValueCAS("foo", []string{"a"}, []string{"c"})
+		if !ok || err != nil {
+			t.Errorf("expected `true` and `nil` got `%v` and `%v`", ok, err)
+		}
+		if value, _ := g.Value("foo"); !equal([]string{"c"}, value.([]string)) {
+			t.Errorf("expected node value `[c]`, got `%v`", value)
+		}
+	})
+	t.Run("concurrent increments", func(t *testing.T) {
+		g := New()
+		g.NewNode("foo", 0)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					for {
+						value, _ := g.Value("foo")
+						if ok, _ := g.UpdateValueCAS("foo", value, value.(int)+1); ok {
+							break
+						}
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		if value, _ := g.Value("foo"); value != 800 {
+			t.Errorf("expected node value `800`, got `%v`", value)
+		}
+	})
+	t.Run("accessing unknown node", func(t *testing.T) {
+		g := New()
+		_, err := g.UpdateValueCAS("foo", nil, nil)
+		if err != ErrorNodeNotFound {
+			t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+		}
+	})
+}
+
+func TestGraphUpdateValueConcurrent(t *testing.T) {
+	g := New()
+	keys := []string{"a", "b", "c", "d"}
+	for _, key := range keys {
+		g.NewNode(key, 0)
+	}
+
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			for i := 1; i <= 1000; i++ {
+				g.UpdateValue(key, i)
+				g.Value(key)
+			}
+		}(key)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			g.NewNode(fmt.Sprint(i), nil)
+			g.NewEdge("a", fmt.Sprint(i))
+			_ = g.String()
+		}
+	}()
+	wg.Wait()
+
+	for _, key := range keys {
+		if value, _ := g.Value(key); value != 1000 {
+			t.Errorf("node `%v`: expected value `1000`, got `%v`", key, value)
+		}
+	}
+}
+
 func TestGraphNewEdge(t *testing.T) {
 	t.Run("existing nodes", func(t *testing.T) {
 		g := New()
@@ -167,6 +278,57 @@ func TestGraphNewEdge(t *testing.T) {
 	})
 }
 
+func TestNewEdgeOrCreate(t *testing.T) {
+	t.Run("without default value", func(t *testing.T) {
+		g := New()
+		g.NewNode("a", 1)
+		g.NewEdgeOrCreate("a", "b")
+		if value, err := g.Value("b"); value != nil || err != nil {
+			t.Errorf("expected `nil` and `nil` got `%v` and `%v`", value, err)
+		}
+		if value, _ := g.Value("a"); value != 1 {
+			t.Errorf("existing node modified, got node value `%v`", value)
+		}
+		if !g.HasEdge("a", "b") {
+			t.Errorf("expected edge `a`->`b` not found.")
+		}
+	})
+	t.Run("with default value", func(t *testing.T) {
+		g := New()
+		g.SetDefaultValue(func(key string) interface{} { return "default " + key })
+		g.NewEdgeOrCreate("a", "b")
+		g.NewEdgeOrCreate("b", "b")
+		for _, key := range []string{"a", "b"} {
+			if value, _ := g.Value(key); value != "default "+key {
+				t.Errorf("node `%v`: unexpected value `%v`", key, value)
+			}
+		}
+		if !g.HasEdge("a", "b") || !g.HasEdge("b", "b") {
+			t.Errorf("expected edges not found:\n%v", g)
+		}
+		if err := g.Verify(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestRedundantEdgeCount(t *testing.T) {
+	g := New()
+	g.NewNode("a", nil)
+	g.NewNode("b", nil)
+	if got := g.RedundantEdgeCount(); got != 0 {
+		t.Errorf("expected `0` got `%v`", got)
+	}
+	g.NewEdge("a", "b")
+	g.NewEdge("b", "a")
+	g.NewEdge("a", "b")
+	g.NewEdge("a", "b")
+	g.NewEdge("a", "unknown")
+	if got := g.RedundantEdgeCount(); got != 2 {
+		t.Errorf("expected `2` got `%v`", got)
+	}
+}
+
 func TestGraphEdges(t *testing.T) {
 	t.Run("existing nodes", func(t *testing.T) {
 		g := New()
@@ -233,6 +395,72 @@ func TestGraphNodes(t *testing.T) {
 	})
 }
 
+func TestGraphConcurrentReadWrite(t *testing.T) {
+	g := New()
+	g.NewNode("root", nil)
+
+	const writers, nodesPerWriter = 4, 100
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < nodesPerWriter; i++ {
+				key := fmt.Sprintf("%v-%v", w, i)
+				g.NewNode(key, i)
+				g.NewEdge("root", key)
+				g.UpdateValue(key, -i)
+			}
+		}(w)
+	}
+
+	done := make(chan struct{})
+	var rwg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		rwg.Add(1)
+		go func() {
+			defer rwg.Done()
+			last := 0
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				edges, err := g.Edges("root")
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				nodes := g.Nodes()
+				count := g.NodeCount()
+				// the graph only grows, so snapshots taken later are larger
+				if len(nodes) < last || count < len(nodes) || len(edges) >= len(nodes) {
+					t.Errorf("inconsistent snapshots: %v nodes, count %v, %v edges",
+						len(nodes), count, len(edges))
+					return
+				}
+				last = len(nodes)
+				// unknown nodes must not leave the graph locked
+				if _, err := g.Edges("unknown"); err != ErrorNodeNotFound {
+					t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	rwg.Wait()
+
+	if got := g.NodeCount(); got != writers*nodesPerWriter+1 {
+		t.Errorf("expected `%v` nodes, got `%v`", writers*nodesPerWriter+1, got)
+	}
+	if edges, _ := g.Edges("root"); len(edges) != writers*nodesPerWriter {
+		t.Errorf("expected `%v` edges, got `%v`", writers*nodesPerWriter, len(edges))
+	}
+}
+
 func TestGraphIsCyclic(t *testing.T) {
 	t.Run("acyclic graph", func(t *testing.T) {
 		g := New()
@@ -328,6 +556,58 @@ func TestTopSort(t *testing.T) {
 	})
 }
 
+func TestDFSOrder(t *testing.T) {
+	g := New()
+	g.NewNode("a", nil)
+	g.NewNode("b", nil)
+	g.NewNode("c", nil)
+	g.NewNode("d", nil)
+	g.NewNode("e", nil)
+	g.NewEdge("a", "b")
+	g.NewEdge("a", "c")
+	g.NewEdge("b", "d")
+	g.NewEdge("c", "d")
+	g.NewEdge("d", "a")
+
+	t.Run("pre-order", func(t *testing.T) {
+		got, err := g.DFSPreOrder("a")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		expected := []string{"a", "b", "d", "c"}
+		if !equal(expected, got) {
+			t.Errorf("expected `%v` got `%v`", expected, got)
+		}
+	})
+	t.Run("post-order", func(t *testing.T) {
+		got, err := g.DFSPostOrder("a")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		expected := []string{"d", "b", "c", "a"}
+		if !equal(expected, got) {
+			t.Errorf("expected `%v` got `%v`", expected, got)
+		}
+	})
+	t.Run("single node", func(t *testing.T) {
+		got, _ := g.DFSPostOrder("e")
+		expected := []string{"e"}
+		if !equal(expected, got) {
+			t.Errorf("expected `%v` got `%v`", expected, got)
+		}
+	})
+	t.Run("unknown node", func(t *testing.T) {
+		_, err := g.DFSPreOrder("unknown")
+		if err != ErrorNodeNotFound {
+			t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+		}
+		_, err = g.DFSPostOrder("unknown")
+		if err != ErrorNodeNotFound {
+			t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+		}
+	})
+}
+
 func TestGraphString(t *testing.T) {
 	t.Run("empty graph", func(t *testing.T) {
 		g := New()
@@ -364,3 +644,1921 @@ func equal(a, b []string) bool {
 	}
 	return true
 }
+
+func TestClassifyEdges(t *testing.T) {
+	t.Run("empty graph", func(t *testing.T) {
+		g := New()
+		if got := g.ClassifyEdges(); len(got) != 0 {
+			t.Errorf("expected no classes, got `%v`", got)
+		}
+	})
+	t.Run("all classes", func(t *testing.T) {
+		g := New()
+		g.NewNode("a", nil)
+		g.NewNode("b", nil)
+		g.NewNode("c", nil)
+		g.NewNode("d", nil)
+		g.NewEdge("a", "b")
+		g.NewEdge("b", "c")
+		g.NewEdge("a", "c")
+		g.NewEdge("c", "a")
+		g.NewEdge("d", "b")
+		got := g.ClassifyEdges()
+		expected := map[string]map[string]string{
+			"a": {"b": EdgeTree, "c": EdgeForward},
+			"b": {"c": EdgeTree},
+			"c": {"a": EdgeBack},
+			"d": {"b": EdgeCross},
+		}
+		for from := range expected {
+			for to, class := range expected[from] {
+				if got[from][to] != class {
+					t.Errorf("edge `%v`->`%v`: expected `%v` got `%v`",
+						from, to, class, got[from][to])
+				}
+			}
+			if len(got[from]) != len(expected[from]) {
+				t.Errorf("node `%v`: expected `%v` got `%v`",
+					from, expected[from], got[from])
+			}
+		}
+	})
+	t.Run("self-referencing node", func(t *testing.T) {
+		g := New()
+		g.NewNode("a", nil)
+		g.NewEdge("a", "a")
+		if got := g.ClassifyEdges()["a"]["a"]; got != EdgeBack {
+			t.Errorf("expected `%v` got `%v`", EdgeBack, got)
+		}
+	})
+}
+
+func TestFeedbackEdgeSet(t *testing.T) {
+	t.Run("acyclic graph", func(t *testing.T) {
+		g := New()
+		for _, nd := range nodes {
+			g.NewNode(nd.key, nd.value)
+		}
+		for _, e := range edges {
+			g.NewEdge(e.from, e.to)
+		}
+		if got := g.FeedbackEdgeSet(); len(got) != 0 {
+			t.Errorf("expected empty set, got `%v`", got)
+		}
+	})
+	t.Run("cyclic graph", func(t *testing.T) {
+		g := New()
+		for _, key := range []string{"a", "b", "c", "d", "e"} {
+			g.NewNode(key, nil)
+		}
+		g.NewEdge("a", "b")
+		g.NewEdge("b", "c")
+		g.NewEdge("c", "a")
+		g.NewEdge("c", "d")
+		g.NewEdge("d", "b")
+		g.NewEdge("d", "e")
+		g.NewEdge("e", "e")
+		set := g.FeedbackEdgeSet()
+		if len(set) == 0 {
+			t.Errorf("expected non-empty set")
+		}
+		for _, e := range set {
+			g.edges[e.From][e.To] = false
+		}
+		if g.IsCyclic() {
+			t.Errorf("graph still cyclic after removing `%v`", set)
+		}
+	})
+}
+
+func TestGreedyColoring(t *testing.T) {
+	t.Run("empty graph", func(t *testing.T) {
+		g := New()
+		if got := g.GreedyColoring(); len(got) != 0 {
+			t.Errorf("expected no colors, got `%v`", got)
+		}
+		if got := g.ChromaticNumberUpperBound(); got != 0 {
+			t.Errorf("expected `0` got `%v`", got)
+		}
+	})
+	t.Run("regular graph", func(t *testing.T) {
+		g := New()
+		for _, key := range []string{"a", "b", "c", "d", "e"} {
+			g.NewNode(key, nil)
+		}
+		g.NewEdge("a", "b")
+		g.NewEdge("b", "c")
+		g.NewEdge("c", "a")
+		g.NewEdge("c", "d")
+		g.NewEdge("d", "d")
+		colors := g.GreedyColoring()
+		if len(colors) != 5 {
+			t.Errorf("expected `5` colors, got `%v`", len(colors))
+		}
+		for from := range g.edges {
+			for to := range g.edges[from] {
+				if from != to && colors[from] == colors[to] {
+					t.Errorf("edge `%v`->`%v` connects same color", from, to)
+				}
+			}
+		}
+		if got := g.ChromaticNumberUpperBound(); got != 3 {
+			t.Errorf("expected `3` got `%v`", got)
+		}
+	})
+}
+
+func TestEdgeWeight(t *testing.T) {
+	g := New()
+	g.NewNode("a", nil)
+	g.NewNode("b", nil)
+	g.NewEdge("a", "b")
+
+	t.Run("default weight", func(t *testing.T) {
+		w, err := g.EdgeWeight("a", "b")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if w != DefaultEdgeWeight {
+			t.Errorf("expected `%v` got `%v`", DefaultEdgeWeight, w)
+		}
+	})
+	t.Run("set weight", func(t *testing.T) {
+		var calls []float64
+		g.OnWeightChanged(func(from, to string, oldWeight, newWeight float64) {
+			// the graph must not be locked when the hook is called
+			w, _ := g.EdgeWeight(from, to)
+			calls = append(calls, oldWeight, newWeight, w)
+		})
+		if err := g.SetEdgeWeight("a", "b", 2.5); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := g.SetEdgeWeight("a", "b", 3); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		expected := []float64{1, 2.5, 2.5, 2.5, 3, 3}
+		if len(calls) != len(expected) {
+			t.Fatalf("expected `%v` got `%v`", expected, calls)
+		}
+		for i := range expected {
+			if calls[i] != expected[i] {
+				t.Errorf("expected `%v` got `%v`", expected, calls)
+			}
+		}
+		g.OnWeightChanged(nil)
+	})
+	t.Run("unknown edge", func(t *testing.T) {
+		if _, err := g.EdgeWeight("b", "a"); err != ErrorEdgeNotFound {
+			t.Errorf("expected `%v` got `%v`", ErrorEdgeNotFound, err)
+		}
+		if err := g.SetEdgeWeight("b", "a", 1); err != ErrorEdgeNotFound {
+			t.Errorf("expected `%v` got `%v`", ErrorEdgeNotFound, err)
+		}
+		if err := g.SetEdgeWeight("x", "y", 1); err != ErrorEdgeNotFound {
+			t.Errorf("expected `%v` got `%v`", ErrorEdgeNotFound, err)
+		}
+	})
+}
+
+type stringer struct{}
+
+func (stringer) String() string { return "stringer" }
+
+func TestNodesImplementing(t *testing.T) {
+	g := New()
+	g.NewNode("a", stringer{})
+	g.NewNode("b", &stringer{})
+	g.NewNode("c", "string")
+	g.NewNode("d", nil)
+
+	t.Run("interface", func(t *testing.T) {
+		got := g.NodesImplementing(reflect.TypeOf((*fmt.Stringer)(nil)).Elem())
+		expected := []string{"a", "b"}
+		if !equal(expected, got) {
+			t.Errorf("expected `%v` got `%v`", expected, got)
+		}
+	})
+	t.Run("concrete type", func(t *testing.T) {
+		got := g.NodesImplementing(reflect.TypeOf(""))
+		expected := []string{"c"}
+		if !equal(expected, got) {
+			t.Errorf("expected `%v` got `%v`", expected, got)
+		}
+	})
+	t.Run("empty interface", func(t *testing.T) {
+		got := g.NodesImplementing(reflect.TypeOf((*interface{})(nil)).Elem())
+		expected := []string{"a", "b", "c"}
+		if !equal(expected, got) {
+			t.Errorf("expected `%v` got `%v`", expected, got)
+		}
+	})
+}
+
+func TestGraphAll(t *testing.T) {
+	g := New()
+	for _, nd := range nodes {
+		g.NewNode(nd.key, nd.value)
+	}
+
+	t.Run("all nodes", func(t *testing.T) {
+		got := make(map[string]interface{})
+		for key, value := range g.All() {
+			got[key] = value
+			// modifying the graph during the iteration must be safe
+			g.UpdateValue(key, value)
+			g.NewNode(key+"'", nil)
+		}
+		if len(got) != len(nodes) {
+			t.Errorf("expected `%v` nodes, got `%v`", len(nodes), len(got))
+		}
+		for _, nd := range nodes {
+			if got[nd.key] != nd.value {
+				t.Errorf("expected node value `%v`, got `%v`", nd.value, got[nd.key])
+			}
+		}
+	})
+	t.Run("break early", func(t *testing.T) {
+		n := 0
+		for range g.All() {
+			n++
+			break
+		}
+		if n != 1 {
+			t.Errorf("expected `1` iteration, got `%v`", n)
+		}
+	})
+}
+
+func TestGraphEdgesSeq(t *testing.T) {
+	g := New()
+	for _, nd := range nodes {
+		g.NewNode(nd.key, nd.value)
+	}
+	for _, e := range edges {
+		g.NewEdge(e.from, e.to)
+	}
+	g.NewEdge("eleven", "foo")
+
+	var got []string
+	for to := range g.EdgesSeq("eleven") {
+		got = append(got, to)
+		g.NewEdge("eleven", "friends")
+	}
+	expected := []string{"foo", "scary"}
+	if !equal(expected, got) {
+		t.Errorf("expected `%v` got `%v`", expected, got)
+	}
+
+	for to := range g.EdgesSeq("unknown") {
+		t.Errorf("unexpected edge to `%v`", to)
+	}
+}
+
+func TestShortestCycleThrough(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "b")
+	g.NewEdge("b", "c")
+	g.NewEdge("c", "d")
+	g.NewEdge("d", "a")
+	g.NewEdge("b", "d")
+	g.NewEdge("e", "e")
+	g.NewEdge("a", "f")
+
+	tests := []struct {
+		key      string
+		expected []string
+		err      error
+	}{
+		{key: "a", expected: []string{"a", "b", "d"}},
+		{key: "c", expected: []string{"c", "d", "a", "b"}},
+		{key: "e", expected: []string{"e"}},
+		{key: "f", err: ErrorNoCycle},
+		{key: "unknown", err: ErrorNodeNotFound},
+	}
+	for _, tc := range tests {
+		got, err := g.ShortestCycleThrough(tc.key)
+		if err != tc.err {
+			t.Errorf("node `%v`: expected `%v` got `%v`", tc.key, tc.err, err)
+		}
+		if !equal(tc.expected, got) {
+			t.Errorf("node `%v`: expected `%v` got `%v`", tc.key, tc.expected, got)
+		}
+	}
+}
+
+func TestHasEdges(t *testing.T) {
+	g := New()
+	for _, nd := range nodes {
+		g.NewNode(nd.key, nd.value)
+	}
+	for _, e := range edges {
+		g.NewEdge(e.from, e.to)
+	}
+
+	for _, e := range edges {
+		if !g.HasEdge(e.from, e.to) {
+			t.Errorf("expected edge `%v`->`%v` not found.", e.from, e.to)
+		}
+	}
+	if g.HasEdge("eleven", "foo") {
+		t.Errorf("unexpected edge `eleven`->`foo`")
+	}
+
+	got := g.HasEdges([][2]string{
+		{"foo", "eleven"},
+		{"eleven", "foo"},
+		{"eleven", "scary"},
+		{"unknown", "foo"},
+	})
+	expected := []bool{true, false, true, false}
+	if len(got) != len(expected) {
+		t.Fatalf("expected `%v` got `%v`", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("expected `%v` got `%v`", expected, got)
+		}
+	}
+}
+
+func TestCondensation(t *testing.T) {
+	t.Run("empty graph", func(t *testing.T) {
+		c, super := New().Condensation()
+		if len(c.nodes) != 0 || len(super) != 0 {
+			t.Errorf("expected empty condensation")
+		}
+	})
+	t.Run("cyclic graph", func(t *testing.T) {
+		g := New()
+		for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+			g.NewNode(key, nil)
+		}
+		g.NewEdge("a", "b")
+		g.NewEdge("b", "a")
+		g.NewEdge("b", "c")
+		g.NewEdge("c", "d")
+		g.NewEdge("d", "e")
+		g.NewEdge("e", "c")
+		g.NewEdge("a", "f")
+		g.NewEdge("f", "f")
+
+		c, super := g.Condensation()
+		if c.IsCyclic() {
+			t.Errorf("condensation is cyclic")
+		}
+		expected := map[string]string{
+			"a": "a", "b": "a", "c": "c", "d": "c", "e": "c", "f": "f",
+		}
+		for key, s := range expected {
+			if super[key] != s {
+				t.Errorf("node `%v`: expected `%v` got `%v`", key, s, super[key])
+			}
+		}
+		if got := c.Nodes(); len(got) != 3 {
+			t.Errorf("expected `3` nodes, got `%v`", got)
+		}
+		if members, _ := c.Value("c"); !equal([]string{"c", "d", "e"}, members.([]string)) {
+			t.Errorf("unexpected members `%v`", members)
+		}
+		if !c.HasEdge("a", "c") || !c.HasEdge("a", "f") || c.HasEdge("f", "f") {
+			t.Errorf("unexpected edges in condensation:\n%v", c)
+		}
+	})
+}
+
+func TestWeightedDegree(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "b")
+	g.NewEdge("a", "c")
+	g.NewEdge("c", "b")
+	g.SetEdgeWeight("a", "b", 2.5)
+	g.SetEdgeWeight("c", "b", 0.5)
+
+	tests := []struct {
+		key     string
+		out, in float64
+	}{
+		{key: "a", out: 2.5 + DefaultEdgeWeight, in: 0},
+		{key: "b", out: 0, in: 3},
+		{key: "c", out: 0.5, in: DefaultEdgeWeight},
+	}
+	for _, tc := range tests {
+		out, err := g.WeightedOutDegree(tc.key)
+		if err != nil || out != tc.out {
+			t.Errorf("node `%v`: expected out-degree `%v` got `%v` (%v)", tc.key, tc.out, out, err)
+		}
+		in, err := g.WeightedInDegree(tc.key)
+		if err != nil || in != tc.in {
+			t.Errorf("node `%v`: expected in-degree `%v` got `%v` (%v)", tc.key, tc.in, in, err)
+		}
+	}
+
+	if _, err := g.WeightedOutDegree("unknown"); err != ErrorNodeNotFound {
+		t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+	}
+	if _, err := g.WeightedInDegree("unknown"); err != ErrorNodeNotFound {
+		t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	setup := func() *DirectedGraph {
+		g := New()
+		for _, nd := range nodes {
+			g.NewNode(nd.key, nd.value)
+		}
+		for _, e := range edges {
+			g.NewEdge(e.from, e.to)
+		}
+		g.SetEdgeWeight("foo", "eleven", 2)
+		return g
+	}
+	if err := setup().Verify(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := New().Verify(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	tests := map[string]func(g *DirectedGraph){
+		"nil node":          func(g *DirectedGraph) { g.nodes["foo"] = nil },
+		"missing edge list": func(g *DirectedGraph) { delete(g.edges, "foo") },
+		"nil edge list":     func(g *DirectedGraph) { g.edges["foo"] = nil },
+		"unknown source":    func(g *DirectedGraph) { g.edges["unknown"] = map[string]bool{} },
+		"unknown target":    func(g *DirectedGraph) { g.edges["foo"]["unknown"] = true },
+		"unknown weight":    func(g *DirectedGraph) { g.weights["eleven"] = map[string]float64{"foo": 1} },
+	}
+	for name, corrupt := range tests {
+		g := setup()
+		corrupt(g)
+		if err := g.Verify(); !errors.Is(err, ErrorGraphInconsistent) {
+			t.Errorf("%v: expected `%v` got `%v`", name, ErrorGraphInconsistent, err)
+		}
+	}
+}
+
+func TestReachableFromAny(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "b")
+	g.NewEdge("b", "c")
+	g.NewEdge("d", "e")
+	g.NewEdge("e", "b")
+
+	tests := []struct {
+		sources  []string
+		expected []string
+		err      error
+	}{
+		{sources: nil, expected: nil},
+		{sources: []string{"a"}, expected: []string{"a", "b", "c"}},
+		{sources: []string{"a", "d", "a"}, expected: []string{"a", "b", "c", "d", "e"}},
+		{sources: []string{"c", "f"}, expected: []string{"c", "f"}},
+		{sources: []string{"a", "unknown"}, err: ErrorNodeNotFound},
+	}
+	for _, tc := range tests {
+		got, err := g.ReachableFromAny(tc.sources)
+		if err != tc.err {
+			t.Errorf("sources `%v`: expected `%v` got `%v`", tc.sources, tc.err, err)
+		}
+		if !equal(tc.expected, got) {
+			t.Errorf("sources `%v`: expected `%v` got `%v`", tc.sources, tc.expected, got)
+		}
+	}
+}
+
+func TestTopSortFrom(t *testing.T) {
+	g := New()
+	for _, key := range []string{"app", "lib", "util", "log", "other", "x", "y"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("app", "lib")
+	g.NewEdge("app", "log")
+	g.NewEdge("lib", "util")
+	g.NewEdge("lib", "log")
+	g.NewEdge("util", "log")
+	g.NewEdge("other", "app")
+	g.NewEdge("x", "y")
+	g.NewEdge("y", "x")
+
+	tests := []struct {
+		target   string
+		expected []string
+		err      error
+	}{
+		{target: "app", expected: []string{"log", "util", "lib", "app"}},
+		{target: "log", expected: []string{"log"}},
+		{target: "x", err: ErrorGraphIsCyclic},
+		{target: "unknown", err: ErrorNodeNotFound},
+	}
+	for _, tc := range tests {
+		got, err := g.TopSortFrom(tc.target)
+		if err != tc.err {
+			t.Errorf("target `%v`: expected `%v` got `%v`", tc.target, tc.err, err)
+		}
+		if !equal(tc.expected, got) {
+			t.Errorf("target `%v`: expected `%v` got `%v`", tc.target, tc.expected, got)
+		}
+	}
+}
+
+func TestStringTree(t *testing.T) {
+	g := New()
+	g.NewNode("app", 1)
+	g.NewNode("lib", 2)
+	g.NewNode("log", 3)
+	g.NewNode("util", 4)
+	g.NewEdge("app", "lib")
+	g.NewEdge("app", "log")
+	g.NewEdge("lib", "log")
+	g.NewEdge("lib", "util")
+	g.NewEdge("util", "app")
+
+	got, err := g.StringTree("app")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	expected := "`app` (1)\n" +
+		"├── `lib` (2)\n" +
+		"│   ├── `log` (3)\n" +
+		"│   └── `util` (4)\n" +
+		"│       └── `app` (see above)\n" +
+		"└── `log` (see above)\n"
+	if got != expected {
+		t.Errorf("expected\n%v\ngot\n%v", expected, got)
+	}
+
+	if _, err := g.StringTree("unknown"); err != ErrorNodeNotFound {
+		t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+	}
+}
+
+func TestEccentricity(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "b")
+	g.NewEdge("b", "c")
+	g.NewEdge("c", "d")
+	g.NewEdge("a", "c")
+	g.NewEdge("d", "a")
+
+	expected := map[string]int{"a": 2, "b": 3, "c": 3, "d": 2, "e": 0}
+	for key, ecc := range expected {
+		got, err := g.Eccentricity(key)
+		if err != nil {
+			t.Errorf("node `%v`: %v", key, err)
+		}
+		if got != ecc {
+			t.Errorf("node `%v`: expected `%v` got `%v`", key, ecc, got)
+		}
+	}
+	if _, err := g.Eccentricity("unknown"); err != ErrorNodeNotFound {
+		t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+	}
+}
+
+func TestDiameter(t *testing.T) {
+	g := New()
+	if got := g.Diameter(); got != 0 {
+		t.Errorf("expected `0` got `%v`", got)
+	}
+	for _, key := range []string{"a", "b", "c", "d"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "b")
+	g.NewEdge("b", "c")
+	g.NewEdge("c", "d")
+	if got := g.Diameter(); got != 3 {
+		t.Errorf("expected `3` got `%v`", got)
+	}
+	g.NewEdge("a", "d")
+	if got := g.Diameter(); got != 2 {
+		t.Errorf("expected `2` got `%v`", got)
+	}
+}
+
+func TestIsIsomorphic(t *testing.T) {
+	build := func(keys []string, edges [][2]string) *DirectedGraph {
+		g := New()
+		for _, key := range keys {
+			g.NewNode(key, nil)
+		}
+		for _, e := range edges {
+			g.NewEdge(e[0], e[1])
+		}
+		return g
+	}
+	g := build([]string{"a", "b", "c", "d"},
+		[][2]string{{"a", "b"}, {"b", "c"}, {"c", "a"}, {"c", "d"}})
+
+	tests := []struct {
+		name     string
+		other    *DirectedGraph
+		expected bool
+		err      error
+	}{
+		{
+			name: "renamed",
+			other: build([]string{"w", "x", "y", "z"},
+				[][2]string{{"z", "y"}, {"y", "x"}, {"x", "z"}, {"x", "w"}}),
+			expected: true,
+		},
+		{
+			name: "reversed edge",
+			other: build([]string{"w", "x", "y", "z"},
+				[][2]string{{"z", "y"}, {"y", "x"}, {"x", "z"}, {"w", "x"}}),
+			expected: false,
+		},
+		{
+			name: "different node count",
+			other: build([]string{"w", "x", "y"},
+				[][2]string{{"z", "y"}, {"y", "x"}}),
+			expected: false,
+		},
+		{
+			name: "different degrees",
+			other: build([]string{"w", "x", "y", "z"},
+				[][2]string{{"w", "x"}, {"x", "w"}, {"y", "z"}, {"z", "y"}}),
+			expected: false,
+		},
+		{
+			name:     "itself",
+			other:    g,
+			expected: true,
+		},
+	}
+	for _, tc := range tests {
+		got, err := g.IsIsomorphic(tc.other)
+		if err != tc.err {
+			t.Errorf("%v: expected `%v` got `%v`", tc.name, tc.err, err)
+		}
+		if got != tc.expected {
+			t.Errorf("%v: expected `%v` got `%v`", tc.name, tc.expected, got)
+		}
+	}
+
+	t.Run("same degrees", func(t *testing.T) {
+		keys := []string{"a", "b", "c", "d", "e", "f"}
+		ring := build(keys, [][2]string{
+			{"a", "b"}, {"b", "c"}, {"c", "d"}, {"d", "e"}, {"e", "f"}, {"f", "a"},
+		})
+		triangles := build(keys, [][2]string{
+			{"a", "b"}, {"b", "c"}, {"c", "a"}, {"d", "e"}, {"e", "f"}, {"f", "d"},
+		})
+		if got, _ := ring.IsIsomorphic(triangles); got {
+			t.Errorf("expected `false` got `%v`", got)
+		}
+	})
+	t.Run("self-references", func(t *testing.T) {
+		a := build([]string{"a", "b"}, [][2]string{{"a", "a"}, {"a", "b"}})
+		b := build([]string{"a", "b"}, [][2]string{{"b", "b"}, {"a", "b"}})
+		if got, _ := a.IsIsomorphic(b); got {
+			t.Errorf("expected `false` got `%v`", got)
+		}
+	})
+	t.Run("too large", func(t *testing.T) {
+		a, b := New(), New()
+		for i := 0; i <= MaxIsomorphismSize; i++ {
+			a.NewNode(fmt.Sprint(i), nil)
+			b.NewNode(fmt.Sprint(i), nil)
+		}
+		if _, err := a.IsIsomorphic(b); err != ErrorGraphTooLarge {
+			t.Errorf("expected `%v` got `%v`", ErrorGraphTooLarge, err)
+		}
+	})
+}
+
+func TestSortedEdges(t *testing.T) {
+	g := New()
+	if got := g.SortedEdges(); len(got) != 0 {
+		t.Errorf("expected no edges, got `%v`", got)
+	}
+	for _, nd := range nodes {
+		g.NewNode(nd.key, nd.value)
+	}
+	for _, e := range edges {
+		g.NewEdge(e.from, e.to)
+	}
+	g.NewEdge("eleven", "foo")
+
+	got := g.SortedEdges()
+	expected := [][2]string{
+		{"eleven", "foo"},
+		{"eleven", "scary"},
+		{"foo", "eleven"},
+		{"friends", "eleven"},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected `%v` got `%v`", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("expected `%v` got `%v`", expected, got)
+		}
+	}
+}
+
+func TestPathWeight(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "b")
+	g.NewEdge("b", "c")
+	g.SetEdgeWeight("b", "c", 2.5)
+
+	tests := []struct {
+		path     []string
+		expected float64
+		err      error
+	}{
+		{path: nil, expected: 0},
+		{path: []string{"a"}, expected: 0},
+		{path: []string{"a", "b"}, expected: DefaultEdgeWeight},
+		{path: []string{"a", "b", "c"}, expected: DefaultEdgeWeight + 2.5},
+		{path: []string{"a", "c"}, err: ErrorEdgeNotFound},
+		{path: []string{"a", "unknown"}, err: ErrorNodeNotFound},
+	}
+	for _, tc := range tests {
+		got, err := g.PathWeight(tc.path)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("path `%v`: expected `%v` got `%v`", tc.path, tc.err, err)
+		}
+		if got != tc.expected {
+			t.Errorf("path `%v`: expected `%v` got `%v`", tc.path, tc.expected, got)
+		}
+	}
+}
+
+func TestSourceSinkPaths(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "b")
+	g.NewEdge("a", "c")
+	g.NewEdge("b", "d")
+	g.NewEdge("c", "d")
+	g.NewEdge("e", "c")
+
+	t.Run("all paths", func(t *testing.T) {
+		got, err := g.SourceSinkPaths(0)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		expected := [][]string{
+			{"a", "b", "d"},
+			{"a", "c", "d"},
+			{"e", "c", "d"},
+			{"f"},
+		}
+		if len(got) != len(expected) {
+			t.Fatalf("expected `%v` got `%v`", expected, got)
+		}
+		for i := range expected {
+			if !equal(expected[i], got[i]) {
+				t.Errorf("expected `%v` got `%v`", expected, got)
+			}
+		}
+	})
+	t.Run("limited paths", func(t *testing.T) {
+		got, _ := g.SourceSinkPaths(2)
+		if len(got) != 2 || !equal([]string{"a", "c", "d"}, got[1]) {
+			t.Errorf("unexpected paths `%v`", got)
+		}
+	})
+	t.Run("cyclic graph", func(t *testing.T) {
+		g.NewEdge("d", "a")
+		if _, err := g.SourceSinkPaths(0); err != ErrorGraphIsCyclic {
+			t.Errorf("expected `%v` got `%v`", ErrorGraphIsCyclic, err)
+		}
+	})
+}
+
+func TestUnion(t *testing.T) {
+	a := New()
+	a.NewNode("x", 1)
+	a.NewNode("y", 2)
+	a.NewEdge("x", "y")
+	a.SetEdgeWeight("x", "y", 5)
+	b := New()
+	b.NewNode("y", 2)
+	b.NewNode("z", 3)
+	b.NewEdge("y", "z")
+	b.NewEdge("z", "y")
+	b.SetEdgeWeight("y", "z", 7)
+
+	t.Run("no conflicts", func(t *testing.T) {
+		u, err := a.Union(b, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := u.Verify(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if got := u.Nodes(); len(got) != 3 {
+			t.Errorf("expected `3` nodes, got `%v`", got)
+		}
+		expected := [][2]string{{"x", "y"}, {"y", "z"}, {"z", "y"}}
+		if got := u.SortedEdges(); len(got) != len(expected) {
+			t.Errorf("expected `%v` got `%v`", expected, got)
+		}
+		if w, _ := u.EdgeWeight("x", "y"); w != 5 {
+			t.Errorf("expected weight `5` got `%v`", w)
+		}
+		if w, _ := u.EdgeWeight("y", "z"); w != 7 {
+			t.Errorf("expected weight `7` got `%v`", w)
+		}
+	})
+	t.Run("conflicts", func(t *testing.T) {
+		b.UpdateValue("y", 20)
+		if _, err := a.Union(b, nil); !errors.Is(err, ErrorValueConflict) {
+			t.Errorf("expected `%v` got `%v`", ErrorValueConflict, err)
+		}
+		u, err := a.Union(b, func(key string, x, y interface{}) interface{} {
+			return x.(int) + y.(int)
+		})
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if value, _ := u.Value("y"); value != 22 {
+			t.Errorf("expected node value `22`, got `%v`", value)
+		}
+		if value, _ := a.Value("y"); value != 2 {
+			t.Errorf("input graph modified, got node value `%v`", value)
+		}
+	})
+}
+
+func TestIntersection(t *testing.T) {
+	a := New()
+	a.NewNode("x", 1)
+	a.NewNode("y", 2)
+	a.NewNode("z", 3)
+	a.NewEdge("x", "y")
+	a.NewEdge("y", "z")
+	a.SetEdgeWeight("y", "z", 4)
+	b := New()
+	b.NewNode("y", 20)
+	b.NewNode("z", 30)
+	b.NewNode("w", 40)
+	b.NewEdge("y", "z")
+	b.NewEdge("z", "y")
+
+	n := a.Intersection(b)
+	if err := n.Verify(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got := n.Nodes(); len(got) != 2 {
+		t.Errorf("expected `2` nodes, got `%v`", got)
+	}
+	if got := n.SortedEdges(); len(got) != 1 || got[0] != [2]string{"y", "z"} {
+		t.Errorf("unexpected edges `%v`", got)
+	}
+	if value, _ := n.Value("y"); value != 2 {
+		t.Errorf("expected node value `2`, got `%v`", value)
+	}
+	if w, _ := n.EdgeWeight("y", "z"); w != 4 {
+		t.Errorf("expected weight `4` got `%v`", w)
+	}
+}
+
+func TestNodesNotReachingSinks(t *testing.T) {
+	g := New()
+	if got := g.NodesNotReachingSinks(); len(got) != 0 {
+		t.Errorf("expected no nodes, got `%v`", got)
+	}
+	for _, key := range []string{"start", "a", "b", "loop1", "loop2", "end", "trap"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("start", "a")
+	g.NewEdge("start", "loop1")
+	g.NewEdge("a", "b")
+	g.NewEdge("b", "a")
+	g.NewEdge("b", "end")
+	g.NewEdge("loop1", "loop2")
+	g.NewEdge("loop2", "loop1")
+	g.NewEdge("trap", "trap")
+
+	got := g.NodesNotReachingSinks()
+	expected := []string{"loop1", "loop2", "trap"}
+	if !equal(expected, got) {
+		t.Errorf("expected `%v` got `%v`", expected, got)
+	}
+}
+
+func TestFindNodes(t *testing.T) {
+	g := New()
+	for _, nd := range nodes {
+		g.NewNode(nd.key, nd.value)
+	}
+	isInt := func(key string, value interface{}) bool {
+		_, ok := value.(int)
+		return ok
+	}
+	never := func(key string, value interface{}) bool { return false }
+
+	key, ok := g.FindNode(isInt)
+	if !ok || (key != "eleven" && key != "scary") {
+		t.Errorf("unexpected node `%v` (%v)", key, ok)
+	}
+	if _, ok := g.FindNode(never); ok {
+		t.Errorf("expected no node")
+	}
+
+	expected := []string{"eleven", "scary"}
+	if got := g.FindNodes(isInt); !equal(expected, got) {
+		t.Errorf("expected `%v` got `%v`", expected, got)
+	}
+	if got := g.FindNodes(never); len(got) != 0 {
+		t.Errorf("expected no nodes, got `%v`", got)
+	}
+}
+
+func TestFindNodesPredicateUsesGraph(t *testing.T) {
+	g := New()
+	for _, nd := range nodes {
+		g.NewNode(nd.key, nd.value)
+	}
+	// pred takes the read lock and adds nodes, which takes the write lock
+	pred := func(key string, value interface{}) bool {
+		v, err := g.Value(key)
+		if err != nil {
+			t.Errorf("unexpected error `%v`", err)
+		}
+		g.NewNode("seen-"+key, nil)
+		_, ok := v.(int)
+		return ok
+	}
+
+	if key, ok := g.FindNode(pred); !ok || (key != "eleven" && key != "scary") {
+		t.Errorf("unexpected node `%v` (%v)", key, ok)
+	}
+	expected := []string{"eleven", "scary"}
+	if got := g.FindNodes(pred); !equal(expected, got) {
+		t.Errorf("expected `%v` got `%v`", expected, got)
+	}
+}
+
+func TestWalkDepth(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "b")
+	g.NewEdge("a", "c")
+	g.NewEdge("b", "d")
+	g.NewEdge("d", "e")
+	g.NewEdge("e", "a")
+
+	walk := func(maxDepth int) ([]string, []int) {
+		var keys []string
+		var depths []int
+		err := g.WalkDepth("a", maxDepth, func(key string, depth int) error {
+			keys = append(keys, key)
+			depths = append(depths, depth)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		return keys, depths
+	}
+
+	keys, depths := walk(2)
+	if !equal([]string{"a", "b", "c", "d"}, keys) {
+		t.Errorf("unexpected keys `%v`", keys)
+	}
+	for i, d := range []int{0, 1, 1, 2} {
+		if depths[i] != d {
+			t.Errorf("unexpected depths `%v`", depths)
+		}
+	}
+	if keys, _ := walk(0); !equal([]string{"a"}, keys) {
+		t.Errorf("unexpected keys `%v`", keys)
+	}
+	if keys, _ := walk(10); len(keys) != 5 {
+		t.Errorf("unexpected keys `%v`", keys)
+	}
+
+	stop := fmt.Errorf("stop")
+	n := 0
+	err := g.WalkDepth("a", 10, func(key string, depth int) error {
+		n++
+		g.UpdateValue(key, depth) // the graph must be usable during the walk
+		if key == "c" {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || n != 3 {
+		t.Errorf("expected `%v` after `3` visits, got `%v` after `%v` visits", stop, err, n)
+	}
+
+	err = g.WalkDepth("unknown", 1, func(string, int) error { return nil })
+	if err != ErrorNodeNotFound {
+		t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+	}
+}
+
+func TestToDOTStructureOnly(t *testing.T) {
+	g := New()
+	g.NewNode("a", "secret")
+	g.NewNode(`b "quoted"`, "secret")
+	g.NewNode("c", "secret")
+	g.NewEdge("a", `b "quoted"`)
+	g.NewEdge("a", "c")
+
+	got := g.ToDOTStructureOnly()
+	expected := "digraph {\n" +
+		"  \"a\";\n" +
+		"  \"b \\\"quoted\\\"\";\n" +
+		"  \"c\";\n" +
+		"  \"a\" -> \"b \\\"quoted\\\"\";\n" +
+		"  \"a\" -> \"c\";\n" +
+		"}\n"
+	if got != expected {
+		t.Errorf("expected\n%v\ngot\n%v", expected, got)
+	}
+	if got := New().ToDOTStructureOnly(); got != "digraph {\n}\n" {
+		t.Errorf("unexpected empty graph `%v`", got)
+	}
+}
+
+func TestTopSortLevels(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "c")
+	g.NewEdge("b", "c")
+	g.NewEdge("b", "d")
+	g.NewEdge("c", "e")
+	g.NewEdge("d", "e")
+	g.NewEdge("a", "e")
+
+	got, err := g.TopSortLevels()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	expected := [][]string{{"a", "b", "f"}, {"c", "d"}, {"e"}}
+	if len(got) != len(expected) {
+		t.Fatalf("expected `%v` got `%v`", expected, got)
+	}
+	for i := range expected {
+		if !equal(expected[i], got[i]) {
+			t.Errorf("expected `%v` got `%v`", expected, got)
+		}
+	}
+
+	g.NewEdge("e", "b")
+	if _, err := g.TopSortLevels(); err != ErrorGraphIsCyclic {
+		t.Errorf("expected `%v` got `%v`", ErrorGraphIsCyclic, err)
+	}
+}
+
+func TestMaxWidth(t *testing.T) {
+	g := New()
+	if got, err := g.MaxWidth(); got != 0 || err != nil {
+		t.Errorf("expected `0` and `nil` got `%v` and `%v`", got, err)
+	}
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "b")
+	g.NewEdge("a", "c")
+	g.NewEdge("a", "d")
+	g.NewEdge("d", "e")
+	if got, err := g.MaxWidth(); got != 3 || err != nil {
+		t.Errorf("expected `3` and `nil` got `%v` and `%v`", got, err)
+	}
+	g.NewEdge("e", "a")
+	if _, err := g.MaxWidth(); err != ErrorGraphIsCyclic {
+		t.Errorf("expected `%v` got `%v`", ErrorGraphIsCyclic, err)
+	}
+}
+
+func TestBetweennessCentrality(t *testing.T) {
+	g := New()
+	if got := g.BetweennessCentrality(); len(got) != 0 {
+		t.Errorf("expected no values, got `%v`", got)
+	}
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		g.NewNode(key, nil)
+	}
+	// a -> b -> d and a -> c -> d are the shortest paths from a to d
+	g.NewEdge("a", "b")
+	g.NewEdge("a", "c")
+	g.NewEdge("b", "d")
+	g.NewEdge("c", "d")
+	g.NewEdge("d", "e")
+	g.NewEdge("e", "e")
+
+	got := g.BetweennessCentrality()
+	expected := map[string]float64{
+		"a": 0,
+		"b": 1, // half of a->d and half of a->e
+		"c": 1,
+		"d": 3, // a->e, b->e, c->e
+		"e": 0,
+	}
+	for key, value := range expected {
+		if got[key] != value {
+			t.Errorf("node `%v`: expected `%v` got `%v`", key, value, got[key])
+		}
+	}
+}
+
+func TestToMermaid(t *testing.T) {
+	g := New()
+	g.NewNode("a", `say "hi" [now]`)
+	g.NewNode("b c", 42)
+	g.NewNode("d", nil)
+	g.NewEdge("a", "b c")
+	g.NewEdge("b c", "d")
+	g.NewEdge("d", "a")
+
+	got := g.ToMermaid()
+	expected := "graph TD\n" +
+		"    n0[\"say #34;hi#34; #91;now#93;\"]\n" +
+		"    n1[\"42\"]\n" +
+		"    n2[\"#60;nil#62;\"]\n" +
+		"    n0 --> n1\n" +
+		"    n1 --> n2\n" +
+		"    n2 --> n0\n"
+	if got != expected {
+		t.Errorf("expected\n%v\ngot\n%v", expected, got)
+	}
+	if got := New().ToMermaid(); got != "graph TD\n" {
+		t.Errorf("unexpected empty graph `%v`", got)
+	}
+}
+
+func TestArticulationPoints(t *testing.T) {
+	g := New()
+	if got := g.ArticulationPoints(); len(got) != 0 {
+		t.Errorf("expected no nodes, got `%v`", got)
+	}
+	for _, key := range []string{"a", "b", "c", "d", "e", "f", "g", "x", "y"} {
+		g.NewNode(key, nil)
+	}
+	// triangle a, b, c attached to d, which connects to the chain e-f
+	g.NewEdge("a", "b")
+	g.NewEdge("b", "c")
+	g.NewEdge("c", "a")
+	g.NewEdge("d", "c")
+	g.NewEdge("d", "e")
+	g.NewEdge("f", "e")
+	g.NewEdge("g", "g")
+	// separate component x-y
+	g.NewEdge("x", "y")
+	g.NewEdge("y", "x")
+
+	got := g.ArticulationPoints()
+	expected := []string{"c", "d", "e"}
+	if !equal(expected, got) {
+		t.Errorf("expected `%v` got `%v`", expected, got)
+	}
+}
+
+func TestTriples(t *testing.T) {
+	g := New()
+	g.NewNode("a", 1)
+	g.NewNode("b", nil)
+	g.NewNode("c", "x")
+	g.NewEdge("a", "b")
+	g.NewEdge("a", "c")
+	g.NewEdge("c", "a")
+
+	check := func(expected, got [][3]string) {
+		if len(got) != len(expected) {
+			t.Fatalf("expected `%v` got `%v`", expected, got)
+		}
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Errorf("expected `%v` got `%v`", expected, got)
+			}
+		}
+	}
+	check([][3]string{
+		{"a", "dependsOn", "b"},
+		{"a", "dependsOn", "c"},
+		{"c", "dependsOn", "a"},
+	}, g.Triples("dependsOn", ""))
+	check([][3]string{
+		{"a", "value", "1"},
+		{"a", "edge", "b"},
+		{"a", "edge", "c"},
+		{"c", "value", "x"},
+		{"c", "edge", "a"},
+	}, g.Triples("edge", "value"))
+}
+
+func TestMinimalRootSet(t *testing.T) {
+	t.Run("acyclic", func(t *testing.T) {
+		g := New()
+		for _, key := range []string{"a", "b", "c", "d", "e"} {
+			g.NewNode(key, nil)
+		}
+		g.NewEdge("a", "c")
+		g.NewEdge("b", "c")
+		g.NewEdge("c", "d")
+
+		got, err := g.MinimalRootSet()
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		expected := []string{"a", "b", "e"}
+		if !equal(expected, got) {
+			t.Errorf("expected `%v` got `%v`", expected, got)
+		}
+	})
+
+	t.Run("cyclic", func(t *testing.T) {
+		g := New()
+		for _, key := range []string{"a", "b", "c", "d", "x", "y"} {
+			g.NewNode(key, nil)
+		}
+		// {c, d} is a source component, {a, b} is entered from it
+		g.NewEdge("d", "c")
+		g.NewEdge("c", "d")
+		g.NewEdge("c", "a")
+		g.NewEdge("a", "b")
+		g.NewEdge("b", "a")
+		// {y} has a self-loop and is entered from nowhere
+		g.NewEdge("y", "y")
+		g.NewEdge("y", "x")
+
+		got, err := g.MinimalRootSet()
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		expected := []string{"c", "y"}
+		if !equal(expected, got) {
+			t.Errorf("expected `%v` got `%v`", expected, got)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		got, err := New().MinimalRootSet()
+		if err != nil || len(got) != 0 {
+			t.Errorf("expected no roots, got `%v`, `%v`", got, err)
+		}
+	})
+}
+
+func TestIsStronglyConnected(t *testing.T) {
+	g := New()
+	if !g.IsStronglyConnected() {
+		t.Errorf("expected empty graph to be strongly connected")
+	}
+	g.NewNode("a", nil)
+	if !g.IsStronglyConnected() {
+		t.Errorf("expected single node to be strongly connected")
+	}
+
+	g.NewNode("b", nil)
+	g.NewNode("c", nil)
+	g.NewEdge("a", "b")
+	g.NewEdge("b", "c")
+	if g.IsStronglyConnected() {
+		t.Errorf("expected path not to be strongly connected")
+	}
+	g.NewEdge("c", "a")
+	if !g.IsStronglyConnected() {
+		t.Errorf("expected cycle to be strongly connected")
+	}
+
+	// d is reachable from every node but cannot reach any
+	g.NewNode("d", nil)
+	g.NewEdge("c", "d")
+	if g.IsStronglyConnected() {
+		t.Errorf("expected graph with sink not to be strongly connected")
+	}
+	g.NewEdge("d", "b")
+	if !g.IsStronglyConnected() {
+		t.Errorf("expected graph to be strongly connected")
+	}
+}
+
+func TestTopSortToQueue(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c", "d"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "b")
+	g.NewEdge("b", "c")
+	g.NewEdge("a", "d")
+	g.NewEdge("d", "c")
+
+	q, err := g.TopSortToQueue()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Len() != 4 {
+		t.Fatalf("expected `%v` got `%v`", 4, q.Len())
+	}
+	position := make(map[string]int)
+	for i := 0; q.Len() > 0; i++ {
+		item, _ := q.Remove()
+		position[item.(string)] = i
+	}
+	for _, edge := range [][2]string{{"a", "b"}, {"b", "c"}, {"a", "d"}, {"d", "c"}} {
+		if position[edge[0]] > position[edge[1]] {
+			t.Errorf("expected `%v` before `%v`", edge[0], edge[1])
+		}
+	}
+
+	g.NewEdge("c", "a")
+	if _, err := g.TopSortToQueue(); err != ErrorGraphIsCyclic {
+		t.Errorf("expected `%v` got `%v`", ErrorGraphIsCyclic, err)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "b")
+	g.NewEdge("a", "a")
+	g.NewEdge("b", "b")
+	g.NewEdge("b", "c")
+	g.SetEdgeWeight("a", "a", 2)
+	g.SetEdgeWeight("a", "b", 3)
+
+	if got := g.Normalize(false); got != 0 {
+		t.Errorf("expected `%v` got `%v`", 0, got)
+	}
+	if !g.HasEdge("a", "a") {
+		t.Errorf("expected self-loop to be kept")
+	}
+
+	// simulate stale entries
+	g.edges["c"]["a"] = false
+	g.edges["c"]["unknown"] = true
+	g.weights["c"] = map[string]float64{"b": 4}
+
+	if got := g.Normalize(true); got != 4 {
+		t.Errorf("expected `%v` got `%v`", 4, got)
+	}
+	if err := g.Verify(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if g.HasEdge("a", "a") || g.HasEdge("b", "b") {
+		t.Errorf("expected self-loops to be removed")
+	}
+	if !g.HasEdge("a", "b") || !g.HasEdge("b", "c") {
+		t.Errorf("expected other edges to be kept")
+	}
+	if w, _ := g.EdgeWeight("a", "b"); w != 3 {
+		t.Errorf("expected `%v` got `%v`", 3, w)
+	}
+	if _, ok := g.weights["a"]["a"]; ok {
+		t.Errorf("expected weight of removed self-loop to be removed")
+	}
+	if _, ok := g.weights["c"]; ok {
+		t.Errorf("expected stale weights to be removed")
+	}
+	if got := g.Normalize(true); got != 0 {
+		t.Errorf("expected `%v` got `%v`", 0, got)
+	}
+}
+
+func TestKeysWithValue(t *testing.T) {
+	g := New()
+	g.NewNode("a", "x")
+	g.NewNode("b", []string{"y"})
+	g.NewNode("c", "x")
+	g.NewNode("d", nil)
+
+	tests := []struct {
+		value    interface{}
+		expected []string
+	}{
+		{"x", []string{"a", "c"}},
+		{[]string{"y"}, []string{"b"}},
+		{nil, []string{"d"}},
+		{"z", nil},
+	}
+	for _, test := range tests {
+		got := g.KeysWithValue(test.value)
+		if !equal(test.expected, got) {
+			t.Errorf("value `%v`: expected `%v` got `%v`", test.value, test.expected, got)
+		}
+	}
+}
+
+func TestDensity(t *testing.T) {
+	g := New()
+	if got := g.Density(); got != 0 {
+		t.Errorf("expected `%v` got `%v`", 0, got)
+	}
+	g.NewNode("a", nil)
+	g.NewEdge("a", "a")
+	if got := g.Density(); got != 0 {
+		t.Errorf("expected `%v` got `%v`", 0, got)
+	}
+
+	g.NewNode("b", nil)
+	if got := g.Density(); got != 0 {
+		t.Errorf("expected `%v` got `%v`", 0, got)
+	}
+	g.NewEdge("a", "b")
+	if got := g.Density(); got != 0.5 {
+		t.Errorf("expected `%v` got `%v`", 0.5, got)
+	}
+	g.NewEdge("b", "a")
+	if got := g.Density(); got != 1 {
+		t.Errorf("expected `%v` got `%v`", 1, got)
+	}
+
+	g.NewNode("c", nil)
+	g.NewNode("d", nil)
+	g.NewEdge("c", "d")
+	// 3 of 12 possible edges
+	if got := g.Density(); got != 0.25 {
+		t.Errorf("expected `%v` got `%v`", 0.25, got)
+	}
+}
+
+func TestShortestPathFunc(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "b")
+	g.NewEdge("b", "e")
+	g.NewEdge("a", "c")
+	g.NewEdge("c", "d")
+	g.NewEdge("d", "e")
+
+	all := func(from, to string) bool { return true }
+	disabled := map[[2]string]bool{{"b", "e"}: true}
+	enabled := func(from, to string) bool { return !disabled[[2]string{from, to}] }
+
+	tests := []struct {
+		from, to string
+		allowed  func(from, to string) bool
+		expected []string
+		err      error
+	}{
+		{"a", "e", all, []string{"a", "b", "e"}, nil},
+		{"a", "e", enabled, []string{"a", "c", "d", "e"}, nil},
+		{"a", "a", all, []string{"a"}, nil},
+		{"e", "a", all, nil, ErrorNoPath},
+		{"a", "e", func(from, to string) bool { return from != "a" }, nil, ErrorNoPath},
+		{"a", "x", all, nil, ErrorNodeNotFound},
+		{"x", "a", all, nil, ErrorNodeNotFound},
+	}
+	for _, test := range tests {
+		got, err := g.ShortestPathFunc(test.from, test.to, test.allowed)
+		if err != test.err {
+			t.Errorf("%v->%v: expected error `%v` got `%v`", test.from, test.to, test.err, err)
+		}
+		if !equal(test.expected, got) {
+			t.Errorf("%v->%v: expected `%v` got `%v`", test.from, test.to, test.expected, got)
+		}
+	}
+
+	// toggling the edge set takes effect immediately
+	disabled[[2]string{"c", "d"}] = true
+	if _, err := g.ShortestPathFunc("a", "e", enabled); err != ErrorNoPath {
+		t.Errorf("expected `%v` got `%v`", ErrorNoPath, err)
+	}
+}
+
+func TestImportEdges(t *testing.T) {
+	t.Run("acyclic", func(t *testing.T) {
+		g := New()
+		g.NewNode("a", "existing")
+		created, cyclic, err := g.ImportEdges([][2]string{
+			{"a", "b"}, {"b", "c"}, {"a", "b"}, {"a", "c"},
+		}, false)
+		if err != nil || cyclic != nil {
+			t.Errorf("unexpected result: `%v`, `%v`", cyclic, err)
+		}
+		if created != 3 {
+			t.Errorf("expected `%v` got `%v`", 3, created)
+		}
+		if got := g.NodeCount(); got != 3 {
+			t.Errorf("expected `%v` got `%v`", 3, got)
+		}
+		if value, _ := g.Value("a"); value != "existing" {
+			t.Errorf("expected `%v` got `%v`", "existing", value)
+		}
+		if got := g.RedundantEdgeCount(); got != 1 {
+			t.Errorf("expected `%v` got `%v`", 1, got)
+		}
+	})
+
+	t.Run("cyclic", func(t *testing.T) {
+		pairs := [][2]string{
+			{"a", "b"}, {"b", "a"}, // first cycle
+			{"b", "c"},
+			{"c", "d"}, {"d", "e"}, {"e", "c"}, // second cycle
+			{"e", "f"},
+			{"g", "g"}, // self-loop
+		}
+
+		g := New()
+		created, cyclic, err := g.ImportEdges(pairs, false)
+		if err != ErrorGraphIsCyclic {
+			t.Errorf("expected `%v` got `%v`", ErrorGraphIsCyclic, err)
+		}
+		expected := []string{"a", "b", "c", "d", "e", "g"}
+		if !equal(expected, cyclic) {
+			t.Errorf("expected `%v` got `%v`", expected, cyclic)
+		}
+		if created != len(pairs) || !g.HasEdge("g", "g") {
+			t.Errorf("expected all edges to be added")
+		}
+
+		g = New()
+		created, cyclic, err = g.ImportEdges(pairs, true)
+		if err != nil || cyclic != nil || created != len(pairs) {
+			t.Errorf("unexpected result: `%v`, `%v`, `%v`", created, cyclic, err)
+		}
+	})
+}
+
+func TestSCCOf(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "b")
+	g.NewEdge("b", "c")
+	g.NewEdge("c", "a")
+	g.NewEdge("c", "d")
+	g.NewEdge("d", "e")
+	g.NewEdge("e", "d")
+
+	tests := []struct {
+		key      string
+		expected []string
+	}{
+		{"a", []string{"a", "b", "c"}},
+		{"c", []string{"a", "b", "c"}},
+		{"d", []string{"d", "e"}},
+	}
+	for _, test := range tests {
+		got, err := g.SCCOf(test.key)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !equal(test.expected, got) {
+			t.Errorf("key `%v`: expected `%v` got `%v`", test.key, test.expected, got)
+		}
+	}
+
+	g.NewNode("f", nil)
+	g.NewEdge("e", "f")
+	if got, _ := g.SCCOf("f"); !equal([]string{"f"}, got) {
+		t.Errorf("expected `%v` got `%v`", []string{"f"}, got)
+	}
+	if _, err := g.SCCOf("x"); err != ErrorNodeNotFound {
+		t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+	}
+}
+
+func TestReverseInPlace(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c"} {
+		g.NewNode(key, key)
+	}
+	g.NewEdge("a", "b")
+	g.NewEdge("b", "c")
+	g.NewEdge("c", "c")
+	g.SetEdgeWeight("a", "b", 2)
+
+	g.ReverseInPlace()
+	if err := g.Verify(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	expected := [][2]string{{"b", "a"}, {"c", "b"}, {"c", "c"}}
+	if got := g.SortedEdges(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected `%v` got `%v`", expected, got)
+	}
+	if w, err := g.EdgeWeight("b", "a"); err != nil || w != 2 {
+		t.Errorf("expected `%v` got `%v`, `%v`", 2, w, err)
+	}
+	if w, _ := g.EdgeWeight("c", "b"); w != DefaultEdgeWeight {
+		t.Errorf("expected `%v` got `%v`", DefaultEdgeWeight, w)
+	}
+	if value, _ := g.Value("a"); value != "a" {
+		t.Errorf("expected `%v` got `%v`", "a", value)
+	}
+
+	g.ReverseInPlace()
+	expected = [][2]string{{"a", "b"}, {"b", "c"}, {"c", "c"}}
+	if got := g.SortedEdges(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected `%v` got `%v`", expected, got)
+	}
+}
+
+func TestIsCutVertexFrom(t *testing.T) {
+	g := New()
+	for _, key := range []string{"r", "a", "b", "c", "d", "x"} {
+		g.NewNode(key, nil)
+	}
+	// d is only reachable through c, b is reachable through a and directly
+	g.NewEdge("r", "a")
+	g.NewEdge("r", "b")
+	g.NewEdge("a", "b")
+	g.NewEdge("b", "c")
+	g.NewEdge("c", "d")
+
+	tests := []struct {
+		root, candidate string
+		expected        bool
+		err             error
+	}{
+		{"r", "a", false, nil},
+		{"r", "b", true, nil},
+		{"r", "c", true, nil},
+		{"r", "d", false, nil},
+		{"r", "x", false, nil},
+		{"r", "r", true, nil},
+		{"x", "x", false, nil},
+		{"a", "b", true, nil},
+		{"r", "unknown", false, ErrorNodeNotFound},
+		{"unknown", "r", false, ErrorNodeNotFound},
+	}
+	for _, test := range tests {
+		got, err := g.IsCutVertexFrom(test.root, test.candidate)
+		if err != test.err {
+			t.Errorf("%v, %v: expected error `%v` got `%v`", test.root, test.candidate, test.err, err)
+		}
+		if got != test.expected {
+			t.Errorf("%v, %v: expected `%v` got `%v`", test.root, test.candidate, test.expected, got)
+		}
+	}
+}
+
+func TestDegreeHistograms(t *testing.T) {
+	g := New()
+	if got := g.InDegreeHistogram(); len(got) != 0 {
+		t.Errorf("expected empty histogram, got `%v`", got)
+	}
+
+	for _, key := range []string{"hub", "a", "b", "c", "d"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "hub")
+	g.NewEdge("b", "hub")
+	g.NewEdge("c", "hub")
+	g.NewEdge("hub", "d")
+	g.NewEdge("a", "d")
+
+	expected := map[int]int{0: 3, 2: 1, 3: 1}
+	if got := g.InDegreeHistogram(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected `%v` got `%v`", expected, got)
+	}
+	expected = map[int]int{0: 1, 1: 3, 2: 1}
+	if got := g.OutDegreeHistogram(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected `%v` got `%v`", expected, got)
+	}
+}
+
+func TestTopSortStream(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "c")
+	g.NewEdge("b", "c")
+	g.NewEdge("c", "d")
+	g.NewEdge("b", "e")
+
+	t.Run("levels", func(t *testing.T) {
+		levels, errs := g.TopSortStream(context.Background())
+		var got [][]string
+		for level := range levels {
+			got = append(got, level)
+		}
+		if err := <-errs; err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		expected, _ := g.TopSortLevels()
+		if !reflect.DeepEqual(expected, got) {
+			t.Errorf("expected `%v` got `%v`", expected, got)
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		levels, errs := g.TopSortStream(ctx)
+		first := <-levels
+		if !equal([]string{"a", "b"}, first) {
+			t.Errorf("expected `%v` got `%v`", []string{"a", "b"}, first)
+		}
+		cancel()
+		if err := <-errs; err != context.Canceled {
+			t.Errorf("expected `%v` got `%v`", context.Canceled, err)
+		}
+		// the levels channel is closed after cancellation
+		for range levels {
+		}
+	})
+
+	t.Run("cyclic", func(t *testing.T) {
+		c := New()
+		c.NewNode("a", nil)
+		c.NewNode("b", nil)
+		c.NewEdge("a", "b")
+		c.NewEdge("b", "a")
+		levels, errs := c.TopSortStream(context.Background())
+		if err := <-errs; err != ErrorGraphIsCyclic {
+			t.Errorf("expected `%v` got `%v`", ErrorGraphIsCyclic, err)
+		}
+		if _, ok := <-levels; ok {
+			t.Errorf("expected no levels")
+		}
+	})
+}
+
+func TestAdjacencyCopy(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "c")
+	g.NewEdge("a", "b")
+	g.NewEdge("b", "b")
+
+	adjacency := g.AdjacencyCopy()
+	expected := map[string][]string{
+		"a": {"b", "c"},
+		"b": {"b"},
+		"c": {},
+	}
+	if !reflect.DeepEqual(expected, adjacency) {
+		t.Errorf("expected `%v` got `%v`", expected, adjacency)
+	}
+
+	// modifying the copy does not affect the graph
+	adjacency["a"][0] = "x"
+	adjacency["c"] = append(adjacency["c"], "a")
+	delete(adjacency, "b")
+	if !g.HasEdge("a", "b") || g.HasEdge("c", "a") || !g.HasEdge("b", "b") {
+		t.Errorf("expected graph to be unchanged")
+	}
+}
+
+func TestTags(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c"} {
+		g.NewNode(key, key)
+	}
+	if err := g.AddTag("x", "external"); err != ErrorNodeNotFound {
+		t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+	}
+	if err := g.RemoveTag("x", "external"); err != ErrorNodeNotFound {
+		t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+	}
+
+	g.AddTag("c", "external")
+	g.AddTag("a", "external")
+	g.AddTag("a", "external")
+	g.AddTag("a", "deprecated")
+	g.AddTag("b", "deprecated")
+
+	if got := g.NodesWithTag("external"); !equal([]string{"a", "c"}, got) {
+		t.Errorf("expected `%v` got `%v`", []string{"a", "c"}, got)
+	}
+	if got := g.NodesWithTag("deprecated"); !equal([]string{"a", "b"}, got) {
+		t.Errorf("expected `%v` got `%v`", []string{"a", "b"}, got)
+	}
+	if got := g.NodesWithTag("unknown"); len(got) != 0 {
+		t.Errorf("expected no nodes, got `%v`", got)
+	}
+
+	if err := g.RemoveTag("a", "external"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := g.RemoveTag("a", "unknown"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got := g.NodesWithTag("external"); !equal([]string{"c"}, got) {
+		t.Errorf("expected `%v` got `%v`", []string{"c"}, got)
+	}
+	// tags are independent of values
+	if value, _ := g.Value("a"); value != "a" {
+		t.Errorf("expected `%v` got `%v`", "a", value)
+	}
+
+	data, _ := g.MarshalJSONVersioned()
+	if err := g.UnmarshalJSONVersioned(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := g.NodesWithTag("deprecated"); !equal([]string{"a", "b"}, got) {
+		t.Errorf("expected `%v` got `%v`", []string{"a", "b"}, got)
+	}
+	if err := g.UnmarshalJSON([]byte(`{"nodes":{"a":null}}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := g.NodesWithTag("deprecated"); len(got) != 0 {
+		t.Errorf("expected tags to be removed, got `%v`", got)
+	}
+}
+
+func TestConnectedClosure(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c", "d", "e", "f", "x"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "b")
+	g.NewEdge("b", "c")
+	g.NewEdge("d", "b")
+	g.NewEdge("e", "f")
+	// x is connected to b via c, but only reachable, not reaching
+	g.NewEdge("c", "x")
+
+	tests := []struct {
+		keys     []string
+		expected []string
+	}{
+		{[]string{"b"}, []string{"a", "b", "c", "d", "x"}},
+		// d reaches b, but a is neither reachable from nor reaching d
+		{[]string{"d"}, []string{"b", "c", "d", "x"}},
+		{[]string{"d", "f"}, []string{"b", "c", "d", "e", "f", "x"}},
+		{[]string{}, nil},
+	}
+	for _, test := range tests {
+		got, err := g.ConnectedClosure(test.keys)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !equal(test.expected, got) {
+			t.Errorf("keys `%v`: expected `%v` got `%v`", test.keys, test.expected, got)
+		}
+	}
+	if _, err := g.ConnectedClosure([]string{"a", "unknown"}); err != ErrorNodeNotFound {
+		t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+	}
+}
+
+func TestIsSpanningTree(t *testing.T) {
+	tree := func() *DirectedGraph {
+		g := New()
+		for _, key := range []string{"ceo", "cto", "cfo", "dev", "ops"} {
+			g.NewNode(key, nil)
+		}
+		g.NewEdge("ceo", "cto")
+		g.NewEdge("ceo", "cfo")
+		g.NewEdge("cto", "dev")
+		g.NewEdge("cto", "ops")
+		return g
+	}
+
+	g := tree()
+	if ok, err := g.IsSpanningTree("ceo"); !ok || err != nil {
+		t.Errorf("expected spanning tree, got `%v`, `%v`", ok, err)
+	}
+	if ok, _ := g.IsSpanningTree("cto"); ok {
+		t.Errorf("expected no spanning tree from non-root")
+	}
+	if _, err := g.IsSpanningTree("unknown"); err != ErrorNodeNotFound {
+		t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(g *DirectedGraph)
+	}{
+		{"two parents", func(g *DirectedGraph) { g.NewEdge("cfo", "ops") }},
+		{"orphan", func(g *DirectedGraph) { g.NewNode("intern", nil) }},
+		{"edge to root", func(g *DirectedGraph) { g.NewEdge("dev", "ceo") }},
+		{"self-loop", func(g *DirectedGraph) { g.NewEdge("ceo", "ceo") }},
+		{"detached cycle", func(g *DirectedGraph) {
+			g.NewNode("a", nil)
+			g.NewNode("b", nil)
+			g.NewEdge("a", "b")
+			g.NewEdge("b", "a")
+		}},
+	}
+	for _, test := range tests {
+		g := tree()
+		test.modify(g)
+		if ok, err := g.IsSpanningTree("ceo"); ok || err != nil {
+			t.Errorf("%v: expected no spanning tree, got `%v`, `%v`", test.name, ok, err)
+		}
+	}
+
+	single := New()
+	single.NewNode("a", nil)
+	if ok, _ := single.IsSpanningTree("a"); !ok {
+		t.Errorf("expected single node to be a spanning tree")
+	}
+}
@@ -0,0 +1,276 @@
+package directedgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// jsonGraph is the JSON representation of a directed graph
+type jsonGraph struct {
+	Nodes map[string]interface{} `json:"nodes"`
+	Edges map[string][]string    `json:"edges"`
+}
+
+// JSONVersion is the version of the format written by MarshalJSONVersioned
+const JSONVersion = 1
+
+// jsonNode is a node in the versioned JSON representation of a directed graph.
+// The tags are omitted for nodes without any.
+type jsonNode struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+	Tags  []string    `json:"tags,omitempty"`
+}
+
+// jsonEdge is an edge in the versioned JSON representation of a directed graph.
+// The weight is omitted for edges with the default weight.
+type jsonEdge struct {
+	From   string   `json:"from"`
+	To     string   `json:"to"`
+	Weight *float64 `json:"weight,omitempty"`
+}
+
+// jsonGraphVersioned is the versioned JSON representation of a directed graph
+type jsonGraphVersioned struct {
+	Version int        `json:"version"`
+	Nodes   []jsonNode `json:"nodes"`
+	Edges   []jsonEdge `json:"edges"`
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (g *DirectedGraph) MarshalJSON() ([]byte, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	jg := jsonGraph{
+		Nodes: make(map[string]interface{}, len(g.nodes)),
+		Edges: make(map[string][]string),
+	}
+	for key, n := range g.nodes {
+		jg.Nodes[key] = n.get()
+		if to := g.successors(key); len(to) > 0 {
+			jg.Edges[key] = to
+		}
+	}
+	return json.Marshal(jg)
+}
+
+// MarshalJSONStructureOnly returns the JSON representation of the graph with
+// all values set to null
+func (g *DirectedGraph) MarshalJSONStructureOnly() ([]byte, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	jg := jsonGraph{
+		Nodes: make(map[string]interface{}, len(g.nodes)),
+		Edges: make(map[string][]string),
+	}
+	for key := range g.nodes {
+		jg.Nodes[key] = nil
+		if to := g.successors(key); len(to) > 0 {
+			jg.Edges[key] = to
+		}
+	}
+	return json.Marshal(jg)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. It replaces all
+// nodes and edges of the graph and removes all tags. Values are decoded into
+// the generic types of the encoding/json package, e.g. numbers become float64.
+func (g *DirectedGraph) UnmarshalJSON(data []byte) error {
+	var jg jsonGraph
+	if err := json.Unmarshal(data, &jg); err != nil {
+		return err
+	}
+
+	nodes := make(map[string]*node, len(jg.Nodes))
+	edges := make(map[string]map[string]bool, len(jg.Nodes))
+	for key, value := range jg.Nodes {
+		nodes[key] = &node{value: value}
+		edges[key] = make(map[string]bool)
+	}
+	for from, tos := range jg.Edges {
+		if _, ok := nodes[from]; !ok {
+			return ErrorNodeNotFound
+		}
+		for _, to := range tos {
+			if _, ok := nodes[to]; !ok {
+				return ErrorNodeNotFound
+			}
+			edges[from][to] = true
+		}
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.nodes = nodes
+	g.edges = edges
+	g.weights = make(map[string]map[string]float64)
+	g.redundantEdges = 0
+	g.tags = make(map[string]map[string]bool)
+	return nil
+}
+
+// MarshalJSONVersioned returns a JSON representation of the graph that carries
+// the version of its format, see JSONVersion. Nodes are sorted by key and
+// edges by their endpoints, so equal graphs always yield identical output.
+// Unlike MarshalJSON, edge weights and tags are preserved.
+func (g *DirectedGraph) MarshalJSONVersioned() ([]byte, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	jg := jsonGraphVersioned{
+		Version: JSONVersion,
+		Nodes:   []jsonNode{},
+		Edges:   []jsonEdge{},
+	}
+	for _, key := range g.sortedNodes() {
+		jn := jsonNode{Key: key, Value: g.nodes[key].get()}
+		for tag := range g.tags[key] {
+			jn.Tags = append(jn.Tags, tag)
+		}
+		sort.Strings(jn.Tags)
+		jg.Nodes = append(jg.Nodes, jn)
+		for _, to := range g.successors(key) {
+			e := jsonEdge{From: key, To: to}
+			if w, ok := g.weights[key][to]; ok {
+				e.Weight = &w
+			}
+			jg.Edges = append(jg.Edges, e)
+		}
+	}
+	return json.Marshal(jg)
+}
+
+// UnmarshalJSONVersioned replaces all nodes, edges, and tags of the graph with
+// the ones decoded from data written by MarshalJSONVersioned.
+// An error wrapping ErrorUnsupportedVersion is returned if data is in an
+// unknown version of the format. Values are decoded into the generic types of
+// the encoding/json package, e.g. numbers become float64.
+func (g *DirectedGraph) UnmarshalJSONVersioned(data []byte) error {
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return err
+	}
+	if header.Version != JSONVersion {
+		return fmt.Errorf("%w: %v", ErrorUnsupportedVersion, header.Version)
+	}
+	var jg jsonGraphVersioned
+	if err := json.Unmarshal(data, &jg); err != nil {
+		return err
+	}
+
+	nodes := make(map[string]*node, len(jg.Nodes))
+	edges := make(map[string]map[string]bool, len(jg.Nodes))
+	weights := make(map[string]map[string]float64)
+	tags := make(map[string]map[string]bool)
+	for _, n := range jg.Nodes {
+		if _, ok := nodes[n.Key]; ok {
+			return fmt.Errorf("node `%v`: %w", n.Key, ErrorNodeAlreadyExists)
+		}
+		nodes[n.Key] = &node{value: n.Value}
+		edges[n.Key] = make(map[string]bool)
+		for _, tag := range n.Tags {
+			if tags[n.Key] == nil {
+				tags[n.Key] = make(map[string]bool)
+			}
+			tags[n.Key][tag] = true
+		}
+	}
+	for _, e := range jg.Edges {
+		for _, key := range []string{e.From, e.To} {
+			if _, ok := nodes[key]; !ok {
+				return fmt.Errorf("node `%v`: %w", key, ErrorNodeNotFound)
+			}
+		}
+		edges[e.From][e.To] = true
+		if e.Weight != nil {
+			if weights[e.From] == nil {
+				weights[e.From] = make(map[string]float64)
+			}
+			weights[e.From][e.To] = *e.Weight
+		}
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.nodes = nodes
+	g.edges = edges
+	g.weights = weights
+	g.redundantEdges = 0
+	g.tags = tags
+	return nil
+}
+
+// SaveToFile writes the versioned JSON representation of the graph to the file
+// at path, see MarshalJSONVersioned, so edge weights and tags are preserved.
+// The data is written to a temporary file first which then replaces the file
+// at path, so that a crash never leaves a truncated file behind. An existing
+// file keeps its permissions, a new file is created with mode 0644.
+func (g *DirectedGraph) SaveToFile(path string) error {
+	data, err := g.MarshalJSONVersioned()
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after successful rename
+
+	// the temporary file is created with mode 0600 and replaces the file
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadFromFile reads a graph from a file written by SaveToFile. Files without a
+// version, as written by MarshalJSON, are read with UnmarshalJSON.
+func LoadFromFile(path string) (*DirectedGraph, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var header struct {
+		Version *int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	g := New()
+	unmarshal := g.UnmarshalJSONVersioned
+	if header.Version == nil {
+		unmarshal = g.UnmarshalJSON
+	}
+	if err := unmarshal(data); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
@@ -0,0 +1,262 @@
+package directedgraph
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGraphJSON(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		g := New()
+		for _, nd := range nodes {
+			g.NewNode(nd.key, nd.value)
+		}
+		for _, e := range edges {
+			g.NewEdge(e.from, e.to)
+		}
+		data, err := json.Marshal(g)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		h := New()
+		if err := json.Unmarshal(data, h); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(h.nodes) != len(nodes) {
+			t.Errorf("expected `%v` nodes, got `%v`", len(nodes), len(h.nodes))
+		}
+		if got := h.nodes["foo"].value; got != "bar" {
+			t.Errorf("expected node value `bar`, got `%v`", got)
+		}
+		for _, e := range edges {
+			if !h.edges[e.from][e.to] {
+				t.Errorf("expected edge `%v`->`%v` not found.", e.from, e.to)
+			}
+		}
+	})
+	t.Run("unknown node", func(t *testing.T) {
+		data := []byte(`{"nodes":{"a":null},"edges":{"a":["b"]}}`)
+		err := New().UnmarshalJSON(data)
+		if err != ErrorNodeNotFound {
+			t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+		}
+	})
+	t.Run("invalid json", func(t *testing.T) {
+		if err := New().UnmarshalJSON([]byte(`{`)); err == nil {
+			t.Errorf("expected error, got `nil`")
+		}
+	})
+}
+
+func TestGraphJSONStructureOnly(t *testing.T) {
+	g := New()
+	g.NewNode("a", "secret")
+	g.NewNode("b", 1337)
+	g.NewEdge("a", "b")
+	data, err := g.MarshalJSONStructureOnly()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"nodes":{"a":null,"b":null},"edges":{"a":["b"]}}`
+	if string(data) != expected {
+		t.Errorf("expected `%s` got `%s`", expected, data)
+	}
+}
+
+func TestGraphFile(t *testing.T) {
+	dir, err := os.MkdirTemp("", "directedgraph")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "graph.json")
+
+	t.Run("save and load", func(t *testing.T) {
+		g := New()
+		g.NewNode("a", "x")
+		g.NewNode("b", "y")
+		g.NewEdge("a", "b")
+		g.SetEdgeWeight("a", "b", 2.5)
+		g.AddTag("b", "leaf")
+		if err := g.SaveToFile(path); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		h, err := LoadFromFile(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got, _ := h.Value("a"); got != "x" {
+			t.Errorf("expected node value `x`, got `%v`", got)
+		}
+		if !h.edges["a"]["b"] {
+			t.Errorf("expected edge `a`->`b` not found.")
+		}
+		if w, _ := h.EdgeWeight("a", "b"); w != 2.5 {
+			t.Errorf("expected weight `2.5` got `%v`", w)
+		}
+		if got := h.NodesWithTag("leaf"); !equal([]string{"b"}, got) {
+			t.Errorf("expected `%v` got `%v`", []string{"b"}, got)
+		}
+		files, _ := os.ReadDir(dir)
+		if len(files) != 1 {
+			t.Errorf("expected `1` file, got `%v`", len(files))
+		}
+	})
+	t.Run("permissions", func(t *testing.T) {
+		modePath := filepath.Join(dir, "mode.json")
+		if err := New().SaveToFile(modePath); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if info, _ := os.Stat(modePath); info.Mode().Perm() != 0644 {
+			t.Errorf("expected mode `%v` got `%v`", os.FileMode(0644), info.Mode().Perm())
+		}
+
+		for _, mode := range []os.FileMode{0640, 0604} {
+			if err := os.Chmod(modePath, mode); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := New().SaveToFile(modePath); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if info, _ := os.Stat(modePath); info.Mode().Perm() != mode {
+				t.Errorf("expected mode `%v` got `%v`", mode, info.Mode().Perm())
+			}
+		}
+		os.Remove(modePath)
+	})
+	t.Run("unversioned file", func(t *testing.T) {
+		oldPath := filepath.Join(dir, "old.json")
+		data := []byte(`{"nodes":{"a":"x","b":"y"},"edges":{"a":["b"]}}`)
+		if err := os.WriteFile(oldPath, data, 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer os.Remove(oldPath)
+		h, err := LoadFromFile(oldPath)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !h.edges["a"]["b"] {
+			t.Errorf("expected edge `a`->`b` not found.")
+		}
+	})
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := LoadFromFile(filepath.Join(dir, "missing")); err == nil {
+			t.Errorf("expected error, got `nil`")
+		}
+	})
+	t.Run("missing directory", func(t *testing.T) {
+		err := New().SaveToFile(filepath.Join(dir, "missing", "graph.json"))
+		if err == nil {
+			t.Errorf("expected error, got `nil`")
+		}
+	})
+}
+
+func TestGraphJSONVersioned(t *testing.T) {
+	t.Run("stable output", func(t *testing.T) {
+		g := New()
+		g.NewNode("b", 2)
+		g.NewNode("a", "x")
+		g.NewNode("c", nil)
+		g.NewEdge("b", "c")
+		g.NewEdge("a", "c")
+		g.NewEdge("a", "b")
+		g.SetEdgeWeight("a", "c", 0.5)
+
+		data, err := g.MarshalJSONVersioned()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := `{"version":1,` +
+			`"nodes":[{"key":"a","value":"x"},{"key":"b","value":2},{"key":"c","value":null}],` +
+			`"edges":[{"from":"a","to":"b"},{"from":"a","to":"c","weight":0.5},{"from":"b","to":"c"}]}`
+		if string(data) != expected {
+			t.Errorf("expected `%v` got `%v`", expected, string(data))
+		}
+
+		h := New()
+		h.NewNode("old", nil)
+		if err := h.UnmarshalJSONVersioned(data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := h.Verify(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if _, err := h.Value("old"); err != ErrorNodeNotFound {
+			t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+		}
+		if w, _ := h.EdgeWeight("a", "c"); w != 0.5 {
+			t.Errorf("expected `%v` got `%v`", 0.5, w)
+		}
+		again, _ := h.MarshalJSONVersioned()
+		if string(again) != string(data) {
+			t.Errorf("expected `%v` got `%v`", string(data), string(again))
+		}
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		g := New()
+		g.NewNode("a", nil)
+		g.NewNode("b", nil)
+		g.AddTag("a", "y")
+		g.AddTag("a", "x")
+
+		data, _ := g.MarshalJSONVersioned()
+		expected := `{"version":1,` +
+			`"nodes":[{"key":"a","value":null,"tags":["x","y"]},{"key":"b","value":null}],` +
+			`"edges":[]}`
+		if string(data) != expected {
+			t.Errorf("expected `%v` got `%v`", expected, string(data))
+		}
+
+		h := New()
+		h.NewNode("b", nil)
+		h.AddTag("b", "x")
+		if err := h.UnmarshalJSONVersioned(data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := h.NodesWithTag("x"); !equal([]string{"a"}, got) {
+			t.Errorf("expected `%v` got `%v`", []string{"a"}, got)
+		}
+		if got := h.NodesWithTag("y"); !equal([]string{"a"}, got) {
+			t.Errorf("expected `%v` got `%v`", []string{"a"}, got)
+		}
+	})
+
+	t.Run("empty graph", func(t *testing.T) {
+		data, _ := New().MarshalJSONVersioned()
+		expected := `{"version":1,"nodes":[],"edges":[]}`
+		if string(data) != expected {
+			t.Errorf("expected `%v` got `%v`", expected, string(data))
+		}
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		tests := []struct {
+			data string
+			err  error
+		}{
+			{`{"version":2,"nodes":[],"edges":[]}`, ErrorUnsupportedVersion},
+			{`{"nodes":[],"edges":[]}`, ErrorUnsupportedVersion},
+			{`{"version":1,"nodes":[{"key":"a"},{"key":"a"}]}`, ErrorNodeAlreadyExists},
+			{`{"version":1,"nodes":[{"key":"a"}],"edges":[{"from":"a","to":"b"}]}`, ErrorNodeNotFound},
+		}
+		for _, test := range tests {
+			g := New()
+			g.NewNode("kept", nil)
+			err := g.UnmarshalJSONVersioned([]byte(test.data))
+			if !errors.Is(err, test.err) {
+				t.Errorf("%v: expected `%v` got `%v`", test.data, test.err, err)
+			}
+			if _, err := g.Value("kept"); err != nil {
+				t.Errorf("expected graph to be unchanged")
+			}
+		}
+		if err := New().UnmarshalJSONVersioned([]byte("{")); err == nil {
+			t.Errorf("expected error, got `nil`")
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package directedgraph
+
+import (
+	"sort"
+	"sync"
+)
+
+// OrderedGraph holds a directed acyclic graph and maintains a topological order
+// of its nodes while edges are added. Edges that would create a cycle are
+// rejected. The order is maintained using the online topological sort
+// algorithm by Pearce and Kelly, which only reorders the nodes affected by a
+// new edge.
+type OrderedGraph struct {
+	lock  sync.RWMutex
+	graph *DirectedGraph
+	pred  map[string]map[string]bool
+	ord   map[string]int // position of a node in order
+	order []string
+}
+
+// NewOrdered initializes a new ordered graph
+func NewOrdered() *OrderedGraph {
+	return &OrderedGraph{
+		graph: New(),
+		pred:  make(map[string]map[string]bool),
+		ord:   make(map[string]int),
+	}
+}
+
+// NewNode adds a new node to the graph
+func (og *OrderedGraph) NewNode(key string, value interface{}) error {
+	og.lock.Lock()
+	defer og.lock.Unlock()
+
+	if err := og.graph.NewNode(key, value); err != nil {
+		return err
+	}
+	og.pred[key] = make(map[string]bool)
+	og.ord[key] = len(og.order)
+	og.order = append(og.order, key)
+	return nil
+}
+
+// Value retrieves the value assigned to the node identified by key
+func (og *OrderedGraph) Value(key string) (interface{}, error) {
+	return og.graph.Value(key)
+}
+
+// forward collects all nodes reachable from key whose position is not greater
+// than ub. It returns false if the node at position ub has been reached.
+func (og *OrderedGraph) forward(seen map[string]bool, delta *[]string, key string, ub int) bool {
+	seen[key] = true
+	*delta = append(*delta, key)
+	for to, active := range og.graph.edges[key] {
+		if !active {
+			continue
+		}
+		if og.ord[to] == ub {
+			return false
+		}
+		if !seen[to] && og.ord[to] < ub {
+			if !og.forward(seen, delta, to, ub) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// backward collects all nodes that reach key and whose position is greater
+// than lb
+func (og *OrderedGraph) backward(seen map[string]bool, delta *[]string, key string, lb int) {
+	seen[key] = true
+	*delta = append(*delta, key)
+	for from := range og.pred[key] {
+		if !seen[from] && og.ord[from] > lb {
+			og.backward(seen, delta, from, lb)
+		}
+	}
+}
+
+// NewEdge adds an edge between two nodes in the graph and updates the
+// topological order. It returns ErrorGraphIsCyclic and leaves the graph
+// unchanged if the edge would create a cycle.
+func (og *OrderedGraph) NewEdge(from, to string) error {
+	og.lock.Lock()
+	defer og.lock.Unlock()
+
+	if _, ok := og.ord[from]; !ok {
+		return ErrorNodeNotFound
+	}
+	if _, ok := og.ord[to]; !ok {
+		return ErrorNodeNotFound
+	}
+	if from == to {
+		return ErrorGraphIsCyclic
+	}
+
+	lb, ub := og.ord[to], og.ord[from]
+	if lb < ub {
+		// the edge violates the current order, reorder the affected region
+		var deltaF, deltaB []string
+		if !og.forward(make(map[string]bool), &deltaF, to, ub) {
+			return ErrorGraphIsCyclic
+		}
+		og.backward(make(map[string]bool), &deltaB, from, lb)
+		og.reorder(deltaB, deltaF)
+	}
+
+	og.pred[to][from] = true
+	return og.graph.NewEdge(from, to)
+}
+
+// reorder moves all nodes of deltaB in front of all nodes of deltaF, reusing
+// the positions that the nodes of both sets occupied before
+func (og *OrderedGraph) reorder(deltaB, deltaF []string) {
+	byOrd := func(keys []string) {
+		sort.Slice(keys, func(i, j int) bool {
+			return og.ord[keys[i]] < og.ord[keys[j]]
+		})
+	}
+	byOrd(deltaB)
+	byOrd(deltaF)
+
+	keys := append(deltaB, deltaF...)
+	positions := make([]int, len(keys))
+	for i, key := range keys {
+		positions[i] = og.ord[key]
+	}
+	sort.Ints(positions)
+	for i, key := range keys {
+		og.ord[key] = positions[i]
+		og.order[positions[i]] = key
+	}
+}
+
+// Order returns the keys of all nodes in topological order
+func (og *OrderedGraph) Order() []string {
+	og.lock.RLock()
+	defer og.lock.RUnlock()
+
+	order := make([]string, len(og.order))
+	copy(order, og.order)
+	return order
+}
@@ -0,0 +1,112 @@
+package directedgraph
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// test helper isTopOrder() checks that no edge of g points backwards in order
+func isTopOrder(g *DirectedGraph, order []string) bool {
+	pos := make(map[string]int)
+	for i, key := range order {
+		pos[key] = i
+	}
+	if len(pos) != len(g.nodes) {
+		return false
+	}
+	for from := range g.edges {
+		for to, active := range g.edges[from] {
+			if active && pos[from] >= pos[to] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestOrderedGraphNewNode(t *testing.T) {
+	og := NewOrdered()
+	if err := og.NewNode("a", 1); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := og.NewNode("a", 1); err != ErrorNodeAlreadyExists {
+		t.Errorf("expected `%v` got `%v`", ErrorNodeAlreadyExists, err)
+	}
+	if value, _ := og.Value("a"); value != 1 {
+		t.Errorf("expected node value `1`, got `%v`", value)
+	}
+}
+
+func TestOrderedGraphNewEdge(t *testing.T) {
+	t.Run("reorder", func(t *testing.T) {
+		og := NewOrdered()
+		for _, key := range []string{"a", "b", "c", "d"} {
+			og.NewNode(key, nil)
+		}
+		og.NewEdge("c", "d")
+		og.NewEdge("d", "a")
+		og.NewEdge("b", "c")
+		if got := og.Order(); !isTopOrder(og.graph, got) {
+			t.Errorf("invalid topological order `%v`", got)
+		}
+		expected := []string{"b", "c", "d", "a"}
+		if got := og.Order(); !equal(expected, got) {
+			t.Errorf("expected `%v` got `%v`", expected, got)
+		}
+	})
+	t.Run("cycle", func(t *testing.T) {
+		og := NewOrdered()
+		for _, key := range []string{"a", "b", "c"} {
+			og.NewNode(key, nil)
+		}
+		og.NewEdge("a", "b")
+		og.NewEdge("b", "c")
+		if err := og.NewEdge("c", "a"); err != ErrorGraphIsCyclic {
+			t.Errorf("expected `%v` got `%v`", ErrorGraphIsCyclic, err)
+		}
+		if err := og.NewEdge("b", "b"); err != ErrorGraphIsCyclic {
+			t.Errorf("expected `%v` got `%v`", ErrorGraphIsCyclic, err)
+		}
+		if og.graph.edges["c"]["a"] || og.graph.edges["b"]["b"] {
+			t.Errorf("rejected edge has been added")
+		}
+	})
+	t.Run("unknown nodes", func(t *testing.T) {
+		og := NewOrdered()
+		og.NewNode("a", nil)
+		if err := og.NewEdge("a", "x"); err != ErrorNodeNotFound {
+			t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+		}
+		if err := og.NewEdge("x", "a"); err != ErrorNodeNotFound {
+			t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+		}
+	})
+	t.Run("random edges", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1337))
+		og := NewOrdered()
+		for i := 0; i < 50; i++ {
+			og.NewNode(fmt.Sprint(i), nil)
+		}
+		for i := 0; i < 500; i++ {
+			from, to := fmt.Sprint(r.Intn(50)), fmt.Sprint(r.Intn(50))
+			err := og.NewEdge(from, to)
+			if err == ErrorGraphIsCyclic {
+				// the edge must close a cycle, i.e. from is reachable from to
+				reachable, _ := og.graph.DFSPreOrder(to)
+				found := false
+				for _, key := range reachable {
+					found = found || key == from
+				}
+				if !found {
+					t.Fatalf("edge `%v`->`%v` rejected without cycle", from, to)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := og.Order(); !isTopOrder(og.graph, got) {
+				t.Fatalf("invalid topological order `%v`", got)
+			}
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package directedgraph
+
+import (
+	"math/bits"
+)
+
+// bitset is a set of small non-negative integers
+type bitset []uint64
+
+// newBitset creates a bitset that can hold the integers 0 to n-1
+func newBitset(n int) bitset {
+	return make(bitset, (n+63)/64)
+}
+
+// set adds i to the set
+func (b bitset) set(i int) {
+	b[i/64] |= 1 << (uint(i) % 64)
+}
+
+// has returns true if i is in the set
+func (b bitset) has(i int) bool {
+	return b[i/64]&(1<<(uint(i)%64)) != 0
+}
+
+// or adds all integers in other to the set
+func (b bitset) or(other bitset) {
+	for i := range b {
+		b[i] |= other[i]
+	}
+}
+
+// count returns the number of integers in the set
+func (b bitset) count() int {
+	n := 0
+	for _, word := range b {
+		n += bits.OnesCount64(word)
+	}
+	return n
+}
+
+// ReachabilityIndex answers reachability queries for all pairs of nodes of a
+// graph in constant time. It is a snapshot of the graph taken when the index
+// was built: later changes to the graph are not reflected in the index, which
+// has to be rebuilt to pick them up.
+type ReachabilityIndex struct {
+	index     map[string]int
+	reachable []bitset
+}
+
+// ReachabilityIndex builds an index of all pairs of nodes for which there is a
+// path from the first to the second node. Building the index takes
+// O(V*(V+E)) time and O(V*V) bits of memory.
+func (g *DirectedGraph) ReachabilityIndex() *ReachabilityIndex {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	keys := g.sortedNodes()
+	r := &ReachabilityIndex{
+		index:     make(map[string]int, len(keys)),
+		reachable: make([]bitset, len(keys)),
+	}
+	for i, key := range keys {
+		r.index[key] = i
+	}
+	for i, key := range keys {
+		r.reachable[i] = newBitset(len(keys))
+		for k := range g.reachable([]string{key}, g.successors) {
+			r.reachable[i].set(r.index[k])
+		}
+	}
+	return r
+}
+
+// CanReach returns true if there is a path from the node identified by from to
+// the node identified by to. Every node can reach itself. CanReach returns
+// false if any of the nodes did not exist when the index was built.
+func (r *ReachabilityIndex) CanReach(from, to string) bool {
+	i, ok := r.index[from]
+	if !ok {
+		return false
+	}
+	j, ok := r.index[to]
+	if !ok {
+		return false
+	}
+	return r.reachable[i].has(j)
+}
+
+// MostImpactfulNode returns the key of the node with the most ancestors, i.e.
+// the most other nodes that can reach it, and the number of its ancestors. Ties
+// are broken in favor of the lexically smallest key. An empty graph yields an
+// empty key and 0. The ancestors of all nodes are computed in a single pass
+// over the strongly connected components of the graph.
+func (g *DirectedGraph) MostImpactfulNode() (string, int) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	keys := g.sortedNodes()
+	index := make(map[string]int, len(keys))
+	for i, key := range keys {
+		index[key] = i
+	}
+	components := g.components()
+	component := make(map[string]int, len(keys))
+	for c, members := range components {
+		for _, key := range members {
+			component[key] = c
+		}
+	}
+	rev := g.reverse()
+
+	// components are in reverse topological order, so the ancestors of all
+	// predecessors of a component are known before the component is visited
+	ancestors := make([]bitset, len(components))
+	best, most := "", -1
+	for c := len(components) - 1; c >= 0; c-- {
+		ancestors[c] = newBitset(len(keys))
+		for _, key := range components[c] {
+			ancestors[c].set(index[key])
+			for _, from := range rev[key] {
+				if p := component[from]; p != c {
+					ancestors[c].or(ancestors[p])
+				}
+			}
+		}
+		// all members of a component share the same ancestors
+		n := ancestors[c].count() - 1
+		if key := components[c][0]; n > most || (n == most && key < best) {
+			best, most = key, n
+		}
+	}
+	if most < 0 {
+		return "", 0
+	}
+	return best, most
+}
@@ -0,0 +1,133 @@
+package directedgraph
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestReachabilityIndex(t *testing.T) {
+	g := New()
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("a", "b")
+	g.NewEdge("b", "c")
+	g.NewEdge("c", "b")
+	g.NewEdge("d", "c")
+
+	r := g.ReachabilityIndex()
+	tests := []struct {
+		from, to string
+		expected bool
+	}{
+		{"a", "a", true},
+		{"a", "b", true},
+		{"a", "c", true},
+		{"c", "b", true},
+		{"d", "b", true},
+		{"b", "a", false},
+		{"a", "d", false},
+		{"a", "e", false},
+		{"e", "a", false},
+		{"a", "x", false},
+		{"x", "a", false},
+	}
+	for _, test := range tests {
+		if got := r.CanReach(test.from, test.to); got != test.expected {
+			t.Errorf("%v->%v: expected `%v` got `%v`", test.from, test.to, test.expected, got)
+		}
+	}
+
+	// the index is a snapshot
+	g.NewEdge("c", "e")
+	if r.CanReach("a", "e") {
+		t.Errorf("expected index not to reflect later changes")
+	}
+	if !g.ReachabilityIndex().CanReach("a", "e") {
+		t.Errorf("expected rebuilt index to reflect changes")
+	}
+}
+
+func TestReachabilityIndexLarge(t *testing.T) {
+	// a chain spanning several words of the bitsets
+	g := New()
+	const n = 150
+	for i := 0; i < n; i++ {
+		g.NewNode(fmt.Sprintf("%03d", i), nil)
+		if i > 0 {
+			g.NewEdge(fmt.Sprintf("%03d", i-1), fmt.Sprintf("%03d", i))
+		}
+	}
+	r := g.ReachabilityIndex()
+	for _, i := range []int{0, 63, 64, 65, 127, 128, 149} {
+		for _, j := range []int{0, 63, 64, 65, 127, 128, 149} {
+			from, to := fmt.Sprintf("%03d", i), fmt.Sprintf("%03d", j)
+			if got := r.CanReach(from, to); got != (i <= j) {
+				t.Errorf("%v->%v: expected `%v` got `%v`", from, to, i <= j, got)
+			}
+		}
+	}
+}
+
+func TestMostImpactfulNode(t *testing.T) {
+	g := New()
+	if key, n := g.MostImpactfulNode(); key != "" || n != 0 {
+		t.Errorf("expected no node, got `%v`, `%v`", key, n)
+	}
+
+	for _, key := range []string{"app", "cli", "lib", "log", "util", "x", "y"} {
+		g.NewNode(key, nil)
+	}
+	g.NewEdge("app", "lib")
+	g.NewEdge("cli", "lib")
+	g.NewEdge("lib", "util")
+	g.NewEdge("lib", "log")
+	g.NewEdge("log", "util")
+	if key, n := g.MostImpactfulNode(); key != "util" || n != 4 {
+		t.Errorf("expected `util`, `4` got `%v`, `%v`", key, n)
+	}
+
+	// x and y depend on each other and on everything else
+	g.NewEdge("util", "x")
+	g.NewEdge("x", "y")
+	g.NewEdge("y", "x")
+	if key, n := g.MostImpactfulNode(); key != "x" || n != 6 {
+		t.Errorf("expected `x`, `6` got `%v`, `%v`", key, n)
+	}
+}
+
+func TestMostImpactfulNodeRandom(t *testing.T) {
+	// compare against a naive search on pseudo random graphs
+	seed := uint32(7)
+	random := func(n uint32) uint32 {
+		seed = seed*1664525 + 1013904223
+		return (seed >> 8) % n
+	}
+	for round := 0; round < 20; round++ {
+		g := New()
+		const n = 40
+		for i := 0; i < n; i++ {
+			g.NewNode(fmt.Sprintf("%02d", i), nil)
+		}
+		for i := 0; i < 60; i++ {
+			g.NewEdge(fmt.Sprintf("%02d", random(n)), fmt.Sprintf("%02d", random(n)))
+		}
+
+		r := g.ReachabilityIndex()
+		best, most := "", -1
+		for _, to := range g.sortedNodes() {
+			count := 0
+			for _, from := range g.sortedNodes() {
+				if from != to && r.CanReach(from, to) {
+					count++
+				}
+			}
+			if count > most {
+				best, most = to, count
+			}
+		}
+		if key, count := g.MostImpactfulNode(); key != best || count != most {
+			t.Errorf("round %v: expected `%v`, `%v` got `%v`, `%v`", round, best, most, key, count)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package directedgraph
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+// TypedGraph holds a directed graph data structure with nodes identified by
+// keys of type K holding values of type V. Unlike DirectedGraph, which is keyed
+// by strings, any comparable type may be used as key.
+type TypedGraph[K comparable, V any] struct {
+	lock  sync.RWMutex
+	nodes map[K]V
+	edges map[K]map[K]bool
+}
+
+// NewTyped initializes a new typed graph
+func NewTyped[K comparable, V any]() *TypedGraph[K, V] {
+	return &TypedGraph[K, V]{
+		nodes: make(map[K]V),
+		edges: make(map[K]map[K]bool),
+	}
+}
+
+// NewNode adds a new node to the graph
+func (g *TypedGraph[K, V]) NewNode(key K, value V) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if _, ok := g.nodes[key]; ok {
+		return ErrorNodeAlreadyExists
+	}
+	g.nodes[key] = value
+	g.edges[key] = make(map[K]bool)
+	return nil
+}
+
+// Value retrieves the value assigned to the node identified by key
+func (g *TypedGraph[K, V]) Value(key K) (V, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	value, ok := g.nodes[key]
+	if !ok {
+		var zero V
+		return zero, ErrorNodeNotFound
+	}
+	return value, nil
+}
+
+// UpdateValue sets the value of the node identified by key
+func (g *TypedGraph[K, V]) UpdateValue(key K, value V) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if _, ok := g.nodes[key]; !ok {
+		return ErrorNodeNotFound
+	}
+	g.nodes[key] = value
+	return nil
+}
+
+// NewEdge adds an edge between two nodes in the graph
+func (g *TypedGraph[K, V]) NewEdge(from, to K) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if _, ok := g.nodes[from]; !ok {
+		return ErrorNodeNotFound
+	}
+	if _, ok := g.nodes[to]; !ok {
+		return ErrorNodeNotFound
+	}
+	g.edges[from][to] = true
+	return nil
+}
+
+// Edges returns the keys of nodes that are directly connected to the node
+func (g *TypedGraph[K, V]) Edges(from K) ([]K, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if _, ok := g.nodes[from]; !ok {
+		return nil, ErrorNodeNotFound
+	}
+	var edges []K
+	for to, active := range g.edges[from] {
+		if active {
+			edges = append(edges, to)
+		}
+	}
+	return edges, nil
+}
+
+// Nodes returns a list of all nodes in the graph
+func (g *TypedGraph[K, V]) Nodes() []K {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	nodes := make([]K, 0, len(g.nodes))
+	for key := range g.nodes {
+		nodes = append(nodes, key)
+	}
+	return nodes
+}
+
+// isCyclicDFS recursively tests nodes for back edges in a depth first way. It
+// expects a `seen` map that it updates and a `rs` (recursion stack) map that it
+// uses to find back edges.
+func (g *TypedGraph[K, V]) isCyclicDFS(seen, rs map[K]bool, key K) bool {
+	seen[key] = true
+	rs[key] = true
+	for to, active := range g.edges[key] {
+		if !active {
+			continue
+		}
+		if rs[to] || (!seen[to] && g.isCyclicDFS(seen, rs, to)) {
+			return true
+		}
+	}
+	rs[key] = false
+	return false
+}
+
+// IsCyclic tests a directed graph for cycles and returns true if a cycle has
+// been detected
+func (g *TypedGraph[K, V]) IsCyclic() bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	seen := make(map[K]bool)
+	rs := make(map[K]bool)
+	for key := range g.nodes {
+		if !seen[key] && g.isCyclicDFS(seen, rs, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// topSort sorts a graph recursively in topological order (non-deterministic)
+func (g *TypedGraph[K, V]) topSort(seen map[K]bool, order []K, i int, key K) int {
+	seen[key] = true
+	for to, active := range g.edges[key] {
+		if active && !seen[to] {
+			i = g.topSort(seen, order, i, to)
+		}
+	}
+	order[i] = key
+	return i - 1
+}
+
+// TopSort returns topological sorted slice of all node keys of the graph. The
+// order is undefined if the graph happens to be cyclic.
+func (g *TypedGraph[K, V]) TopSort() []K {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	order := make([]K, len(g.nodes))
+	i := len(order) - 1
+	seen := make(map[K]bool)
+	for key := range g.nodes {
+		if !seen[key] {
+			i = g.topSort(seen, order, i, key)
+		}
+	}
+	return order
+}
+
+// String returns a human readable multi-line string describing the graph. Keys
+// and values are formatted using their default format, so keys implementing
+// fmt.Stringer are printed using their String method.
+func (g *TypedGraph[K, V]) String() string {
+	var out bytes.Buffer
+
+	g.lock.RLock()
+	for key, value := range g.nodes {
+		out.WriteString(fmt.Sprintf("⦿ `%v` (%v)\n", key, value))
+		for to, active := range g.edges[key] {
+			if active {
+				out.WriteString(fmt.Sprintf("⤷ `%v`\n", to))
+			}
+		}
+	}
+	g.lock.RUnlock()
+
+	return out.String()
+}
@@ -0,0 +1,99 @@
+package directedgraph
+
+import (
+	"strings"
+	"testing"
+)
+
+type nodeID int
+
+func (id nodeID) String() string {
+	return "#" + string(rune('0'+id))
+}
+
+func TestTypedGraphNodes(t *testing.T) {
+	g := NewTyped[nodeID, float64]()
+	if err := g.NewNode(1, 1.5); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := g.NewNode(1, 2); err != ErrorNodeAlreadyExists {
+		t.Errorf("expected `%v` got `%v`", ErrorNodeAlreadyExists, err)
+	}
+	if value, err := g.Value(1); err != nil || value != 1.5 {
+		t.Errorf("expected node value `1.5`, got `%v` (%v)", value, err)
+	}
+	if err := g.UpdateValue(1, 2.5); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if value, _ := g.Value(1); value != 2.5 {
+		t.Errorf("expected node value `2.5`, got `%v`", value)
+	}
+	if _, err := g.Value(2); err != ErrorNodeNotFound {
+		t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+	}
+	if err := g.UpdateValue(2, 0); err != ErrorNodeNotFound {
+		t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+	}
+	if got := g.Nodes(); len(got) != 1 || got[0] != 1 {
+		t.Errorf("expected `[1]` got `%v`", got)
+	}
+}
+
+func TestTypedGraphEdges(t *testing.T) {
+	g := NewTyped[int, string]()
+	g.NewNode(1, "a")
+	g.NewNode(2, "b")
+	if err := g.NewEdge(1, 2); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := g.NewEdge(1, 3); err != ErrorNodeNotFound {
+		t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+	}
+	if err := g.NewEdge(3, 1); err != ErrorNodeNotFound {
+		t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+	}
+	if to, err := g.Edges(1); err != nil || len(to) != 1 || to[0] != 2 {
+		t.Errorf("expected `[2]` got `%v` (%v)", to, err)
+	}
+	if _, err := g.Edges(3); err != ErrorNodeNotFound {
+		t.Errorf("expected `%v` got `%v`", ErrorNodeNotFound, err)
+	}
+}
+
+func TestTypedGraphTopSort(t *testing.T) {
+	g := NewTyped[int, struct{}]()
+	for i := 1; i <= 4; i++ {
+		g.NewNode(i, struct{}{})
+	}
+	g.NewEdge(4, 3)
+	g.NewEdge(3, 2)
+	g.NewEdge(2, 1)
+	g.NewEdge(4, 1)
+	if g.IsCyclic() {
+		t.Errorf("expected `false` got `true`")
+	}
+	got := g.TopSort()
+	expected := []int{4, 3, 2, 1}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("expected `%v` got `%v`", expected, got)
+		}
+	}
+	g.NewEdge(1, 4)
+	if !g.IsCyclic() {
+		t.Errorf("expected `true` got `false`")
+	}
+}
+
+func TestTypedGraphString(t *testing.T) {
+	g := NewTyped[nodeID, string]()
+	g.NewNode(1, "a")
+	g.NewNode(2, "b")
+	g.NewEdge(1, 2)
+	got := g.String()
+	for _, s := range []string{"⦿ `#1` (a)\n⤷ `#2`\n", "⦿ `#2` (b)\n"} {
+		if !strings.Contains(got, s) {
+			t.Errorf("expected `%v` in `%v`", s, got)
+		}
+	}
+}
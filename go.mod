@@ -1,7 +1,10 @@
 module github.com/danrl/golibby
 
+go 1.23
+
+require github.com/stretchr/testify v1.2.0
+
 require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/testify v1.2.0
 )
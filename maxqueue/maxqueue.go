@@ -1,6 +1,7 @@
 package maxqueue
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -12,6 +13,7 @@ type MaxQueue struct {
 	lock   sync.RWMutex
 	queue  queue.Queue
 	maxlen int
+	space  *sync.Cond // signals removed items to waiting producers
 }
 
 var (
@@ -28,9 +30,11 @@ func New(maxlen int) (*MaxQueue, error) {
 	if maxlen < 1 {
 		return nil, ErrorIllegalLength
 	}
-	return &MaxQueue{
+	q := &MaxQueue{
 		maxlen: maxlen,
-	}, nil
+	}
+	q.space = sync.NewCond(&q.lock)
+	return q, nil
 }
 
 // Len returns the number of items in the maxqueue
@@ -70,5 +74,35 @@ func (q *MaxQueue) Remove() (interface{}, error) {
 	if err != nil {
 		return nil, ErrorEmpty
 	}
+	q.space.Broadcast()
 	return item, nil
 }
+
+// TryAdd adds an item at the end of the maxqueue if it is not full. It returns
+// true if the item has been added.
+func (q *MaxQueue) TryAdd(item interface{}) bool {
+	return q.Add(item) == nil
+}
+
+// AddWait adds an item at the end of the maxqueue. If the maxqueue is full, it
+// blocks until an item is removed or the context is done, in which case the
+// context's error is returned and the item is not added.
+func (q *MaxQueue) AddWait(ctx context.Context, item interface{}) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	stop := context.AfterFunc(ctx, func() {
+		q.lock.Lock()
+		defer q.lock.Unlock()
+		q.space.Broadcast()
+	})
+	defer stop()
+	for q.queue.Len() >= q.maxlen {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		q.space.Wait()
+	}
+	q.queue.Add(item)
+	return nil
+}
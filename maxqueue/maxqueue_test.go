@@ -1,7 +1,9 @@
 package maxqueue
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -85,3 +87,69 @@ func TestPeek(t *testing.T) {
 	assert.Equal(t, 1337, item)
 	assert.Equal(t, 1, q.Len())
 }
+
+func TestTryAdd(t *testing.T) {
+	q, _ := New(2)
+	assert.Equal(t, true, q.TryAdd(1))
+	assert.Equal(t, true, q.TryAdd(2))
+	assert.Equal(t, false, q.TryAdd(3))
+	assert.Equal(t, 2, q.Len())
+
+	q.Remove()
+	assert.Equal(t, true, q.TryAdd(3))
+	item, _ := q.Remove()
+	assert.Equal(t, 2, item)
+	item, _ = q.Remove()
+	assert.Equal(t, 3, item)
+}
+
+func TestAddWait(t *testing.T) {
+	t.Run("space available", func(t *testing.T) {
+		q, _ := New(1)
+		assert.Equal(t, nil, q.AddWait(context.Background(), 1))
+		assert.Equal(t, 1, q.Len())
+	})
+
+	t.Run("blocks until removed", func(t *testing.T) {
+		q, _ := New(1)
+		q.Add(1)
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			q.Remove()
+		}()
+		assert.Equal(t, nil, q.AddWait(context.Background(), 2))
+		item, _ := q.Peek()
+		assert.Equal(t, 2, item)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		q, _ := New(1)
+		q.Add(1)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.Equal(t, context.DeadlineExceeded, q.AddWait(ctx, 2))
+		assert.Equal(t, 1, q.Len())
+	})
+
+	t.Run("backpressure", func(t *testing.T) {
+		q, _ := New(2)
+		const items = 100
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < items; i++ {
+				assert.Equal(t, nil, q.AddWait(context.Background(), i))
+				assert.True(t, q.Len() <= 2)
+			}
+		}()
+		for i := 0; i < items; {
+			item, err := q.Remove()
+			if err == ErrorEmpty {
+				continue
+			}
+			assert.Equal(t, i, item)
+			i++
+		}
+		<-done
+	})
+}
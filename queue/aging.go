@@ -0,0 +1,95 @@
+package queue
+
+import (
+	"sync"
+	"time"
+)
+
+// agingItem is an item of an aging priority queue
+type agingItem struct {
+	item     interface{}
+	priority int
+	added    time.Time
+}
+
+// AgingPriorityQueue represents a priority queue in which the effective
+// priority of an item increases by one for every rate it spends waiting in the
+// queue. This prevents items of low priority from starving.
+type AgingPriorityQueue struct {
+	lock sync.RWMutex
+	data []agingItem
+	rate time.Duration
+	now  func() time.Time
+}
+
+// NewAgingPriorityQueue creates a new aging priority queue. A rate of zero or
+// less disables aging.
+func NewAgingPriorityQueue(rate time.Duration) *AgingPriorityQueue {
+	return &AgingPriorityQueue{
+		rate: rate,
+		now:  time.Now,
+	}
+}
+
+// Len returns the number of items in the queue
+func (q *AgingPriorityQueue) Len() int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return len(q.data)
+}
+
+// Add adds an item with the given priority to the queue. Items with a higher
+// priority are removed first.
+func (q *AgingPriorityQueue) Add(item interface{}, priority int) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.data = append(q.data, agingItem{
+		item:     item,
+		priority: priority,
+		added:    q.now(),
+	})
+}
+
+// effective returns the priority of an item after aging
+func (q *AgingPriorityQueue) effective(ai agingItem, now time.Time) float64 {
+	if q.rate <= 0 {
+		return float64(ai.priority)
+	}
+	return float64(ai.priority) + float64(now.Sub(ai.added))/float64(q.rate)
+}
+
+// next returns the index of the item with the highest effective priority.
+// Items of equal effective priority are returned in the order they were added.
+func (q *AgingPriorityQueue) next() int {
+	now := q.now()
+	best := 0
+	for i := 1; i < len(q.data); i++ {
+		if q.effective(q.data[i], now) > q.effective(q.data[best], now) {
+			best = i
+		}
+	}
+	return best
+}
+
+// Peek returns the item with the highest effective priority without removing it
+func (q *AgingPriorityQueue) Peek() (interface{}, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if len(q.data) == 0 {
+		return nil, ErrorEmpty
+	}
+	return q.data[q.next()].item, nil
+}
+
+// Remove returns the item with the highest effective priority
+func (q *AgingPriorityQueue) Remove() (interface{}, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if len(q.data) == 0 {
+		return nil, ErrorEmpty
+	}
+	i := q.next()
+	item := q.data[i].item
+	q.data = append(q.data[:i], q.data[i+1:]...)
+	return item, nil
+}
@@ -0,0 +1,59 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgingPriorityQueue(t *testing.T) {
+	now := time.Unix(0, 0)
+	q := NewAgingPriorityQueue(time.Second)
+	q.now = func() time.Time { return now }
+
+	_, err := q.Peek()
+	assert.Equal(t, ErrorEmpty, err)
+	_, err = q.Remove()
+	assert.Equal(t, ErrorEmpty, err)
+
+	q.Add("low", 0)
+	now = now.Add(500 * time.Millisecond)
+	q.Add("high", 1)
+	q.Add("high too", 1)
+	assert.Equal(t, 3, q.Len())
+
+	// "low" has aged by half a priority level only
+	item, err := q.Peek()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "high", item)
+	item, _ = q.Remove()
+	assert.Equal(t, "high", item)
+
+	// "low" has now aged by more than a priority level and takes precedence
+	// over newer items of higher priority
+	now = now.Add(1500 * time.Millisecond)
+	q.Add("high again", 1)
+	item, _ = q.Remove()
+	assert.Equal(t, "high too", item)
+	item, _ = q.Remove()
+	assert.Equal(t, "low", item)
+	item, _ = q.Remove()
+	assert.Equal(t, "high again", item)
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestAgingPriorityQueueNoAging(t *testing.T) {
+	now := time.Unix(0, 0)
+	q := NewAgingPriorityQueue(0)
+	q.now = func() time.Time { return now }
+
+	q.Add("low", 0)
+	now = now.Add(time.Hour)
+	q.Add("high", 1)
+
+	item, _ := q.Remove()
+	assert.Equal(t, "high", item)
+	item, _ = q.Remove()
+	assert.Equal(t, "low", item)
+}
@@ -0,0 +1,79 @@
+package queue
+
+import (
+	"sync"
+)
+
+// DropPolicy determines which item a bounded queue drops when it is full
+type DropPolicy int
+
+const (
+	// DropOldest drops the first item of the queue to make room for a new item
+	DropOldest DropPolicy = iota
+	// DropNewest rejects new items
+	DropNewest
+)
+
+// BoundedQueue represents a queue holding a limited number of items. When it
+// is full, adding an item drops an item according to the queue's DropPolicy.
+// With DropNewest it behaves like FixedQueue, except for how Add reports a
+// full queue.
+type BoundedQueue struct {
+	lock   sync.RWMutex
+	ring   ring
+	policy DropPolicy
+}
+
+// NewBoundedQueue creates a new queue that holds up to size items and drops
+// items according to policy when it is full. The size must not be negative.
+func NewBoundedQueue(size int, policy DropPolicy) *BoundedQueue {
+	return &BoundedQueue{
+		ring:   newRing(size),
+		policy: policy,
+	}
+}
+
+// Len returns the number of items in the queue
+func (q *BoundedQueue) Len() int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.ring.length
+}
+
+// Cap returns the maximum number of items the queue can hold
+func (q *BoundedQueue) Cap() int {
+	return len(q.ring.data)
+}
+
+// Add adds an item at the end of the queue. If the queue is full, an item is
+// dropped according to the queue's policy and returned together with true:
+// with DropOldest the first item of the queue is dropped and the new item is
+// added, with DropNewest the new item itself is dropped. A queue that holds no
+// items at all always drops the new item.
+func (q *BoundedQueue) Add(item interface{}) (dropped interface{}, overflow bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.ring.full() {
+		if q.policy == DropNewest || len(q.ring.data) == 0 {
+			return item, true
+		}
+		dropped, _ = q.ring.pop()
+		overflow = true
+	}
+	q.ring.push(item)
+	return dropped, overflow
+}
+
+// Peek returns the first item from the queue without removing it
+func (q *BoundedQueue) Peek() (interface{}, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.ring.peek()
+}
+
+// Remove returns the first item from the queue
+func (q *BoundedQueue) Remove() (interface{}, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.ring.pop()
+}
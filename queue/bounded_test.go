@@ -0,0 +1,79 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoundedQueue(t *testing.T) {
+	t.Run("drop oldest", func(t *testing.T) {
+		q := NewBoundedQueue(3, DropOldest)
+		assert.Equal(t, 3, q.Cap())
+		_, err := q.Peek()
+		assert.Equal(t, ErrorEmpty, err)
+		_, err = q.Remove()
+		assert.Equal(t, ErrorEmpty, err)
+
+		for i := 0; i < 3; i++ {
+			dropped, overflow := q.Add(i)
+			assert.Equal(t, nil, dropped)
+			assert.Equal(t, false, overflow)
+		}
+		dropped, overflow := q.Add(3)
+		assert.Equal(t, 0, dropped)
+		assert.Equal(t, true, overflow)
+		dropped, overflow = q.Add(4)
+		assert.Equal(t, 1, dropped)
+		assert.Equal(t, true, overflow)
+		assert.Equal(t, 3, q.Len())
+
+		for i := 2; i <= 4; i++ {
+			item, err := q.Peek()
+			assert.Equal(t, nil, err)
+			assert.Equal(t, i, item)
+			item, err = q.Remove()
+			assert.Equal(t, nil, err)
+			assert.Equal(t, i, item)
+		}
+		assert.Equal(t, 0, q.Len())
+	})
+
+	t.Run("drop newest", func(t *testing.T) {
+		q := NewBoundedQueue(2, DropNewest)
+		q.Add(1)
+		q.Add(2)
+		dropped, overflow := q.Add(3)
+		assert.Equal(t, 3, dropped)
+		assert.Equal(t, true, overflow)
+		assert.Equal(t, 2, q.Len())
+		item, _ := q.Remove()
+		assert.Equal(t, 1, item)
+
+		dropped, overflow = q.Add(4)
+		assert.Equal(t, nil, dropped)
+		assert.Equal(t, false, overflow)
+		item, _ = q.Remove()
+		assert.Equal(t, 2, item)
+		item, _ = q.Remove()
+		assert.Equal(t, 4, item)
+	})
+
+	t.Run("dropped nil item", func(t *testing.T) {
+		q := NewBoundedQueue(1, DropOldest)
+		q.Add(nil)
+		dropped, overflow := q.Add(1)
+		assert.Equal(t, nil, dropped)
+		assert.Equal(t, true, overflow)
+	})
+
+	t.Run("zero size", func(t *testing.T) {
+		for _, policy := range []DropPolicy{DropOldest, DropNewest} {
+			q := NewBoundedQueue(0, policy)
+			dropped, overflow := q.Add(1)
+			assert.Equal(t, 1, dropped)
+			assert.Equal(t, true, overflow)
+			assert.Equal(t, 0, q.Len())
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// delayItem is an item of a delay queue
+type delayItem struct {
+	item  interface{}
+	ready time.Time
+	seq   uint64 // keeps items with equal ready time in insertion order
+}
+
+// delayHeap is a min-heap of delay items ordered by ready time
+type delayHeap []delayItem
+
+func (h delayHeap) Len() int { return len(h) }
+func (h delayHeap) Less(i, j int) bool {
+	if h[i].ready.Equal(h[j].ready) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].ready.Before(h[j].ready)
+}
+func (h delayHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *delayHeap) Push(x interface{}) { *h = append(*h, x.(delayItem)) }
+func (h *delayHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// DelayQueue represents a queue in which items become available for removal
+// only after a given delay has elapsed
+type DelayQueue struct {
+	lock  sync.Mutex
+	data  delayHeap
+	seq   uint64
+	added chan struct{} // closed and replaced whenever an item is added
+}
+
+// NewDelayQueue creates a new delay queue
+func NewDelayQueue() *DelayQueue {
+	return &DelayQueue{
+		added: make(chan struct{}),
+	}
+}
+
+// Len returns the number of items in the queue, including items that are not
+// ready yet
+func (q *DelayQueue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.data)
+}
+
+// AddAfter adds an item to the queue that becomes available after d
+func (q *DelayQueue) AddAfter(item interface{}, d time.Duration) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	heap.Push(&q.data, delayItem{
+		item:  item,
+		ready: time.Now().Add(d),
+		seq:   q.seq,
+	})
+	q.seq++
+	close(q.added)
+	q.added = make(chan struct{})
+}
+
+// Remove returns the item that became available first. It returns ErrorEmpty if
+// no item is available yet.
+func (q *DelayQueue) Remove() (interface{}, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if len(q.data) == 0 || q.data[0].ready.After(time.Now()) {
+		return nil, ErrorEmpty
+	}
+	return heap.Pop(&q.data).(delayItem).item, nil
+}
+
+// RemoveWait returns the item that became available first. It blocks until an
+// item is available or the context is done, in which case the context's error
+// is returned.
+func (q *DelayQueue) RemoveWait(ctx context.Context) (interface{}, error) {
+	for {
+		q.lock.Lock()
+		var timer *time.Timer
+		var wait <-chan time.Time
+		if len(q.data) > 0 {
+			d := time.Until(q.data[0].ready)
+			if d <= 0 {
+				item := heap.Pop(&q.data).(delayItem).item
+				q.lock.Unlock()
+				return item, nil
+			}
+			timer = time.NewTimer(d)
+			wait = timer.C
+		}
+		added := q.added
+		q.lock.Unlock()
+
+		select {
+		case <-wait:
+		case <-added:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelayQueue(t *testing.T) {
+	q := NewDelayQueue()
+	_, err := q.Remove()
+	assert.Equal(t, ErrorEmpty, err)
+
+	q.AddAfter("later", time.Hour)
+	q.AddAfter("first", 0)
+	q.AddAfter("second", 0)
+	assert.Equal(t, 3, q.Len())
+
+	item, err := q.Remove()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "first", item)
+	item, err = q.Remove()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "second", item)
+
+	_, err = q.Remove()
+	assert.Equal(t, ErrorEmpty, err)
+	assert.Equal(t, 1, q.Len())
+}
+
+func TestDelayQueueRemoveWait(t *testing.T) {
+	t.Run("wait for delay", func(t *testing.T) {
+		q := NewDelayQueue()
+		// the delay starts within AddAfter, so start must be taken before
+		start := time.Now()
+		q.AddAfter("item", 10*time.Millisecond)
+		item, err := q.RemoveWait(context.Background())
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "item", item)
+		assert.True(t, time.Since(start) >= 10*time.Millisecond)
+	})
+	t.Run("wait for add", func(t *testing.T) {
+		q := NewDelayQueue()
+		q.AddAfter("later", time.Hour)
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			q.AddAfter("sooner", 0)
+		}()
+		item, err := q.RemoveWait(context.Background())
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "sooner", item)
+	})
+	t.Run("context done", func(t *testing.T) {
+		q := NewDelayQueue()
+		q.AddAfter("later", time.Hour)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := q.RemoveWait(ctx)
+		assert.Equal(t, context.DeadlineExceeded, err)
+		assert.Equal(t, 1, q.Len())
+	})
+}
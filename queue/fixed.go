@@ -0,0 +1,65 @@
+package queue
+
+import (
+	"sync"
+)
+
+// FixedQueue represents a queue of fixed size. It is backed by a ring buffer
+// that is allocated once on creation, so adding and removing items never
+// allocates.
+type FixedQueue struct {
+	lock sync.RWMutex
+	ring ring
+}
+
+// NewFixedQueue creates a new queue that holds up to size items. The size must
+// not be negative.
+func NewFixedQueue(size int) *FixedQueue {
+	return &FixedQueue{
+		ring: newRing(size),
+	}
+}
+
+// Len returns the number of items in the queue
+func (q *FixedQueue) Len() int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.ring.length
+}
+
+// Cap returns the maximum number of items the queue can hold
+func (q *FixedQueue) Cap() int {
+	return len(q.ring.data)
+}
+
+// Add adds an item at the end of the queue or returns ErrorFull if there is no
+// space left
+func (q *FixedQueue) Add(item interface{}) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.ring.full() {
+		return ErrorFull
+	}
+	q.ring.push(item)
+	return nil
+}
+
+// Peek returns the first item from the queue without removing it
+func (q *FixedQueue) Peek() (interface{}, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.ring.peek()
+}
+
+// Remove returns the first item from the queue
+func (q *FixedQueue) Remove() (interface{}, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.ring.pop()
+}
+
+// TryAdd adds an item at the end of the queue if there is space left. It
+// returns true if the item has been added.
+func (q *FixedQueue) TryAdd(item interface{}) bool {
+	return q.Add(item) == nil
+}
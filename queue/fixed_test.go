@@ -0,0 +1,70 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixedQueue(t *testing.T) {
+	q := NewFixedQueue(3)
+	assert.Equal(t, 0, q.Len())
+	assert.Equal(t, 3, q.Cap())
+
+	_, err := q.Peek()
+	assert.Equal(t, ErrorEmpty, err)
+	_, err = q.Remove()
+	assert.Equal(t, ErrorEmpty, err)
+
+	assert.Equal(t, nil, q.Add(1))
+	assert.Equal(t, nil, q.Add(2))
+	assert.Equal(t, nil, q.Add(3))
+	assert.Equal(t, ErrorFull, q.Add(4))
+	assert.Equal(t, 3, q.Len())
+
+	item, err := q.Peek()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, item)
+
+	// wrap around the end of the ring buffer
+	for i := 4; i < 10; i++ {
+		item, err := q.Remove()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, i-3, item)
+		assert.Equal(t, nil, q.Add(i))
+	}
+	for i := 7; i < 10; i++ {
+		item, err := q.Remove()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, i, item)
+	}
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestFixedQueueZeroSize(t *testing.T) {
+	q := NewFixedQueue(0)
+	assert.Equal(t, ErrorFull, q.Add(1))
+	_, err := q.Remove()
+	assert.Equal(t, ErrorEmpty, err)
+}
+
+func TestFixedQueueAllocs(t *testing.T) {
+	q := NewFixedQueue(8)
+	item := interface{}(1337)
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = q.Add(item)
+		_, _ = q.Remove()
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func TestFixedQueueTryAdd(t *testing.T) {
+	q := NewFixedQueue(1)
+	assert.Equal(t, true, q.TryAdd(1))
+	assert.Equal(t, false, q.TryAdd(2))
+	assert.Equal(t, 1, q.Len())
+	item, _ := q.Remove()
+	assert.Equal(t, 1, item)
+
+	assert.Equal(t, false, NewFixedQueue(0).TryAdd(1))
+}
@@ -0,0 +1,47 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/gob"
+	"time"
+)
+
+// GobEncode implements the gob.GobEncoder interface. The items are encoded in
+// the order they would be removed. Items are encoded as interface values, so
+// their concrete types must be registered using gob.Register.
+func (q *Queue) GobEncode() ([]byte, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(q.data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements the gob.GobDecoder interface. It replaces all items of
+// the queue with the decoded items. The statistics of the queue, e.g.
+// TotalEnqueued, are not changed. If the queue tracks wait times, the decoded
+// items are timestamped with the time of decoding.
+func (q *Queue) GobDecode(data []byte) error {
+	var items []interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&items); err != nil {
+		return err
+	}
+
+	q.lock.Lock()
+	defer q.unlock(len(q.data))
+	q.data = items
+	if q.now != nil {
+		now := q.now()
+		q.added = make([]time.Time, len(items))
+		for i := range q.added {
+			q.added[i] = now
+		}
+	}
+	if len(q.data) > 0 {
+		q.broadcast()
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type gobJob struct {
+	ID   int
+	Name string
+}
+
+func TestGob(t *testing.T) {
+	gob.Register(gobJob{})
+
+	t.Run("round trip", func(t *testing.T) {
+		q := &Queue{}
+		q.Add(gobJob{ID: 1, Name: "first"})
+		q.Add("second")
+		q.Add(3)
+		q.Remove() // the front of the queue is not encoded
+
+		var buf bytes.Buffer
+		assert.Equal(t, nil, gob.NewEncoder(&buf).Encode(q))
+		assert.Equal(t, 2, q.Len())
+
+		r := &Queue{}
+		r.Add("discarded")
+		assert.Equal(t, nil, gob.NewDecoder(&buf).Decode(r))
+		assert.Equal(t, 2, r.Len())
+		item, _ := r.Remove()
+		assert.Equal(t, "second", item)
+		item, _ = r.Remove()
+		assert.Equal(t, 3, item)
+
+		r.Add(gobJob{ID: 4})
+		item, _ = r.Peek()
+		assert.Equal(t, gobJob{ID: 4}, item)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		data, err := (&Queue{}).GobEncode()
+		assert.Equal(t, nil, err)
+		r := &Queue{}
+		r.Add(1)
+		assert.Equal(t, nil, r.GobDecode(data))
+		assert.Equal(t, 0, r.Len())
+	})
+
+	t.Run("unregistered type", func(t *testing.T) {
+		type unregistered struct{ X int }
+		q := &Queue{}
+		q.Add(unregistered{X: 1})
+		_, err := q.GobEncode()
+		assert.NotEqual(t, nil, err)
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		r := &Queue{}
+		r.Add(1)
+		assert.NotEqual(t, nil, r.GobDecode([]byte("garbage")))
+		assert.Equal(t, 1, r.Len())
+	})
+}
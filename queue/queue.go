@@ -1,21 +1,72 @@
 package queue
 
 import (
+	"context"
 	"fmt"
+	"iter"
+	"reflect"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// Queue represents a queue
+// Queue represents a queue. It is safe for concurrent use. Each item added is
+// removed at most once, i.e. it is handed out by at most one call to Remove,
+// RemoveAt, RemoveWhile, ReplaceFront, Partition, MoveAllTo, or ProcessAll.
+// Truncate discards items without handing them out. Items are removed in the
+// order they were added, but there is no guarantee about which of several
+// concurrent consumers receives the next item.
+//
+// Adding and removing items are serialized by a single lock, so all items form
+// one global stream in the order in which the calls to Add acquired the lock.
+// In particular, items added by a single producer are removed in the order they
+// were added, and each consumer receives them in that order, no matter how many
+// consumers there are. Only methods that explicitly reorder items, e.g. Rotate,
+// Reverse, SortBy, or RemoveAt, break this order.
 type Queue struct {
-	lock sync.RWMutex
-	data []interface{}
+	lock     sync.RWMutex
+	data     []interface{}
+	enqueued atomic.Uint64
+	dequeued atomic.Uint64
+	cond     *sync.Cond // signals changes to waiting callers, created on demand
+	growth   func(curCap int) int
+
+	onEmpty    func()
+	onNonEmpty func()
+
+	// wait time tracking, see New
+	now       func() time.Time // nil unless wait times are tracked
+	added     []time.Time      // times the items in data were added
+	totalWait time.Duration
+	waited    int
 }
 
 var (
 	// ErrorEmpty is returned on illegal operations on an empty queue
 	ErrorEmpty = fmt.Errorf("empty queue")
+	// ErrorFull is returned on illegal operations on a full queue
+	ErrorFull = fmt.Errorf("full queue")
+	// ErrorIndexOutOfRange is returned when accessing a position that does not
+	// exist in the queue
+	ErrorIndexOutOfRange = fmt.Errorf("index out of range")
+	// ErrorUnknownBand is returned when adding an item to a priority band that
+	// does not exist
+	ErrorUnknownBand = fmt.Errorf("unknown band")
 )
 
+// New creates a new queue. If trackWait is true, items are timestamped when
+// they are added and the time they spent in the queue is recorded when they are
+// removed, see AverageWait. The zero value of Queue is an empty queue that does
+// not track wait times.
+func New(trackWait bool) *Queue {
+	q := &Queue{}
+	if trackWait {
+		q.now = time.Now
+	}
+	return q
+}
+
 // Len returns the number of items in the queue
 func (q *Queue) Len() int {
 	q.lock.RLock()
@@ -23,11 +74,100 @@ func (q *Queue) Len() int {
 	return len(q.data)
 }
 
-// Add adds an item at the end of the queue
-func (q *Queue) Add(item interface{}) {
+// Cap returns the capacity of the slice backing the queue
+func (q *Queue) Cap() int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return cap(q.data)
+}
+
+// TotalEnqueued returns the number of items that have been added to the queue
+// over its lifetime
+func (q *Queue) TotalEnqueued() uint64 {
+	return q.enqueued.Load()
+}
+
+// TotalDequeued returns the number of items that have been removed from the
+// queue over its lifetime, including items discarded by Truncate
+func (q *Queue) TotalDequeued() uint64 {
+	return q.dequeued.Load()
+}
+
+// SetGrowthPolicy sets a function that determines the new capacity of the slice
+// backing the queue whenever an item is added to a full slice. It is called with
+// the current capacity. Results too small to hold the new item are ignored. If
+// no policy is set, or policy is nil, the slice grows as defined by append.
+func (q *Queue) SetGrowthPolicy(policy func(curCap int) int) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
+	q.growth = policy
+}
+
+// grow makes room for at least one more item according to the growth policy.
+// The caller must hold the write lock.
+func (q *Queue) grow() {
+	if q.growth == nil || len(q.data) < cap(q.data) {
+		return
+	}
+	newCap := q.growth(cap(q.data))
+	if newCap <= len(q.data) {
+		return
+	}
+	data := make([]interface{}, len(q.data), newCap)
+	copy(data, q.data)
+	q.data = data
+}
+
+// push adds an item at the end of the queue. The caller must hold the write
+// lock.
+func (q *Queue) push(item interface{}) {
+	q.grow()
 	q.data = append(q.data, item)
+	if q.now != nil {
+		q.added = append(q.added, q.now())
+	}
+	q.enqueued.Add(1)
+	q.broadcast()
+}
+
+// recordWait records the wait times of removed items that were added at the
+// given times. The caller must hold the write lock and only call recordWait if
+// wait times are tracked.
+func (q *Queue) recordWait(added []time.Time) {
+	now := q.now()
+	for _, t := range added {
+		q.totalWait += now.Sub(t)
+	}
+	q.waited += len(added)
+}
+
+// AverageWait returns the average time the items removed so far have spent in
+// the queue. It returns 0 if no item has been removed yet or if the queue does
+// not track wait times, see New. Items discarded by Truncate or GobDecode are
+// not included.
+func (q *Queue) AverageWait() time.Duration {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if q.waited == 0 {
+		return 0
+	}
+	return q.totalWait / time.Duration(q.waited)
+}
+
+// Add adds an item at the end of the queue
+func (q *Queue) Add(item interface{}) {
+	q.lock.Lock()
+	defer q.unlock(len(q.data))
+	q.push(item)
+}
+
+// AddLen adds an item at the end of the queue and returns the resulting number
+// of items in the queue
+func (q *Queue) AddLen(item interface{}) int {
+	q.lock.Lock()
+	defer q.unlock(len(q.data))
+	q.push(item)
+	return len(q.data)
 }
 
 // Peek returns the first item from the queue without removing it
@@ -42,12 +182,502 @@ func (q *Queue) Peek() (interface{}, error) {
 
 // Remove returns the first item from the queue
 func (q *Queue) Remove() (interface{}, error) {
+	q.lock.Lock()
+	defer q.unlock(len(q.data))
+	if len(q.data) == 0 {
+		return nil, ErrorEmpty
+	}
+	item := q.data[0]
+	q.data = q.data[1:]
+	if q.now != nil {
+		q.recordWait(q.added[:1])
+		q.added = q.added[1:]
+	}
+	q.dequeued.Add(1)
+	return item, nil
+}
+
+// ReplaceFront swaps the first item of the queue with item and returns the
+// previous first item
+func (q *Queue) ReplaceFront(item interface{}) (interface{}, error) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 	if len(q.data) == 0 {
 		return nil, ErrorEmpty
 	}
+	old := q.data[0]
+	q.data[0] = item
+	if q.now != nil {
+		q.recordWait(q.added[:1])
+		q.added[0] = q.now()
+	}
+	return old, nil
+}
+
+// Rotate moves the first item of the queue to the end of the queue
+func (q *Queue) Rotate() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if len(q.data) == 0 {
+		return ErrorEmpty
+	}
 	item := q.data[0]
 	q.data = q.data[1:]
+	q.grow()
+	q.data = append(q.data, item)
+	if q.now != nil {
+		q.added = append(q.added[1:], q.added[0])
+	}
+	return nil
+}
+
+// All returns an iterator over the positions and items of the queue from front
+// to back. The iterator works on a snapshot of the queue that is taken when the
+// iteration starts, so the queue may be modified during the iteration.
+func (q *Queue) All() iter.Seq2[int, interface{}] {
+	return func(yield func(int, interface{}) bool) {
+		q.lock.RLock()
+		data := make([]interface{}, len(q.data))
+		copy(data, q.data)
+		q.lock.RUnlock()
+
+		for i, item := range data {
+			if !yield(i, item) {
+				return
+			}
+		}
+	}
+}
+
+// RemoveWhile removes items from the front of the queue as long as f returns
+// true for them. It stops at the first item for which f returns false, leaving
+// that item in the queue, and returns the removed items in order. The queue is
+// locked while f is called, so f must not use the queue.
+func (q *Queue) RemoveWhile(f func(item interface{}) bool) []interface{} {
+	q.lock.Lock()
+	defer q.unlock(len(q.data))
+	i := 0
+	for i < len(q.data) && f(q.data[i]) {
+		i++
+	}
+	items := make([]interface{}, i)
+	copy(items, q.data[:i])
+	q.data = q.data[i:]
+	if q.now != nil {
+		q.recordWait(q.added[:i])
+		q.added = q.added[i:]
+	}
+	q.dequeued.Add(uint64(i))
+	return items
+}
+
+// Compact reallocates the slice backing the queue to exactly fit the items in
+// the queue, releasing all unused capacity
+func (q *Queue) Compact() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if len(q.data) == 0 {
+		q.data = nil
+		q.added = nil
+		return
+	}
+	data := make([]interface{}, len(q.data))
+	copy(data, q.data)
+	q.data = data
+	if q.now != nil {
+		added := make([]time.Time, len(q.added))
+		copy(added, q.added)
+		q.added = added
+	}
+}
+
+// Clone returns a new queue holding the same items as the queue. The items
+// themselves are not copied. If the queue tracks wait times, so does the clone,
+// starting with the times the items were added to the queue.
+func (q *Queue) Clone() *Queue {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	data := make([]interface{}, len(q.data))
+	copy(data, q.data)
+	c := &Queue{data: data, now: q.now}
+	if q.now != nil {
+		c.added = make([]time.Time, len(q.added))
+		copy(c.added, q.added)
+	}
+	return c
+}
+
+// Reverse reverses the order of the items in the queue
+func (q *Queue) Reverse() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for i, j := 0, len(q.data)-1; i < j; i, j = i+1, j-1 {
+		q.swap(i, j)
+	}
+}
+
+// swap swaps the items at positions i and j. The caller must hold the write
+// lock.
+func (q *Queue) swap(i, j int) {
+	q.data[i], q.data[j] = q.data[j], q.data[i]
+	if q.now != nil {
+		q.added[i], q.added[j] = q.added[j], q.added[i]
+	}
+}
+
+// sortable sorts the items of a queue using less
+type sortable struct {
+	q    *Queue
+	less func(a, b interface{}) bool
+}
+
+func (s sortable) Len() int           { return len(s.q.data) }
+func (s sortable) Less(i, j int) bool { return s.less(s.q.data[i], s.q.data[j]) }
+func (s sortable) Swap(i, j int)      { s.q.swap(i, j) }
+
+// SortBy sorts the items of the queue using less. The sort is stable, so items
+// that are equal according to less keep their order. The queue is locked while
+// less is called, so less must not use the queue.
+func (q *Queue) SortBy(less func(a, b interface{}) bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	sort.Stable(sortable{q: q, less: less})
+}
+
+// broadcast wakes up all callers waiting for the queue to change. The caller
+// must hold the write lock.
+func (q *Queue) broadcast() {
+	if q.cond != nil {
+		q.cond.Broadcast()
+	}
+}
+
+// waitUntil blocks until done returns true or the context is done, in which
+// case the context's error is returned. The caller must hold the write lock.
+func (q *Queue) waitUntil(ctx context.Context, done func() bool) error {
+	if q.cond == nil {
+		q.cond = sync.NewCond(&q.lock)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		q.lock.Lock()
+		defer q.lock.Unlock()
+		q.cond.Broadcast()
+	})
+	defer stop()
+	for !done() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		q.cond.Wait()
+	}
+	return nil
+}
+
+// PeekWait returns the first item from the queue without removing it. If the
+// queue is empty, it blocks until an item is added or the context is done, in
+// which case the context's error is returned.
+func (q *Queue) PeekWait(ctx context.Context) (interface{}, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	err := q.waitUntil(ctx, func() bool { return len(q.data) > 0 })
+	if err != nil {
+		return nil, err
+	}
+	return q.data[0], nil
+}
+
+// Partition removes all items from the queue and distributes them to two new
+// queues, one holding the items for which pred returns true and one holding the
+// rest. Both keep the relative order of the items. If the queue tracks wait
+// times, so do the new queues, starting when the items are added to them. The
+// queue is locked while pred is called, so pred must not use the queue.
+func (q *Queue) Partition(pred func(item interface{}) bool) (match, rest *Queue) {
+	q.lock.Lock()
+	defer q.unlock(len(q.data))
+	match, rest = &Queue{now: q.now}, &Queue{now: q.now}
+	for _, item := range q.data {
+		if pred(item) {
+			match.push(item)
+		} else {
+			rest.push(item)
+		}
+	}
+	if q.now != nil {
+		q.recordWait(q.added)
+		q.added = nil
+	}
+	q.dequeued.Add(uint64(len(q.data)))
+	q.data = nil
+	return match, rest
+}
+
+// Truncate discards all items of the queue but keeps the slice backing it, so
+// that items can be added again without allocating. Discarded items are
+// counted as dequeued, so TotalEnqueued minus TotalDequeued still equals Len.
+func (q *Queue) Truncate() {
+	q.lock.Lock()
+	defer q.unlock(len(q.data))
+	q.dequeued.Add(uint64(len(q.data)))
+	clear(q.data) // allow the items to be garbage collected
+	q.data = q.data[:0]
+	q.added = q.added[:0]
+}
+
+// IndexOf returns the position of the first item that is deeply equal to item,
+// with 0 being the front of the queue, and whether such an item was found
+func (q *Queue) IndexOf(item interface{}) (int, bool) {
+	return q.IndexFunc(func(other interface{}) bool {
+		return reflect.DeepEqual(item, other)
+	})
+}
+
+// IndexFunc returns the position of the first item for which match returns
+// true, with 0 being the front of the queue, and whether such an item was
+// found. The queue is locked while match is called, so match must not use the
+// queue.
+func (q *Queue) IndexFunc(match func(item interface{}) bool) (int, bool) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	for i, item := range q.data {
+		if match(item) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// RemoveAt removes and returns the item at position i, with 0 being the front
+// of the queue. The items behind it move up by one position.
+func (q *Queue) RemoveAt(i int) (interface{}, error) {
+	q.lock.Lock()
+	defer q.unlock(len(q.data))
+	if len(q.data) == 0 {
+		return nil, ErrorEmpty
+	}
+	if i < 0 || i >= len(q.data) {
+		return nil, ErrorIndexOutOfRange
+	}
+	item := q.data[i]
+	copy(q.data[i:], q.data[i+1:])
+	q.data[len(q.data)-1] = nil // allow the item to be garbage collected
+	q.data = q.data[:len(q.data)-1]
+	if q.now != nil {
+		q.recordWait(q.added[i : i+1])
+		q.added = append(q.added[:i], q.added[i+1:]...)
+	}
+	q.dequeued.Add(1)
 	return item, nil
 }
+
+// Reduce folds the items of the queue from front to back into an accumulator,
+// starting with initial, and returns the result. The queue is not modified. The
+// queue is locked while f is called, so f must not use the queue.
+func (q *Queue) Reduce(initial interface{}, f func(acc, item interface{}) interface{}) interface{} {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	acc := initial
+	for _, item := range q.data {
+		acc = f(acc, item)
+	}
+	return acc
+}
+
+// CopyTo copies up to len(dst) items from the front of the queue into dst and
+// returns the number of items copied. The queue is not modified.
+func (q *Queue) CopyTo(dst []interface{}) int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return copy(dst, q.data)
+}
+
+// OnEmpty registers a function that is called whenever the queue becomes empty
+// because items have been removed. It replaces any previously registered
+// function, nil disables the hook. The function is called after the queue has
+// been unlocked, so it may use the queue.
+func (q *Queue) OnEmpty(f func()) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.onEmpty = f
+}
+
+// OnNonEmpty registers a function that is called whenever an item is added to
+// the empty queue. It replaces any previously registered function, nil
+// disables the hook. The function is called after the queue has been unlocked,
+// so it may use the queue.
+func (q *Queue) OnNonEmpty(f func()) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.onNonEmpty = f
+}
+
+// transitionHook returns the OnEmpty or OnNonEmpty hook if the queue has become
+// empty or non-empty, given that it held before items when the lock was
+// acquired, or nil. The caller must hold the write lock.
+func (q *Queue) transitionHook(before int) func() {
+	if before == 0 && len(q.data) > 0 {
+		return q.onNonEmpty
+	} else if before > 0 && len(q.data) == 0 {
+		return q.onEmpty
+	}
+	return nil
+}
+
+// unlock releases the write lock and calls the OnEmpty or OnNonEmpty hook if
+// the queue has become empty or non-empty, given that it held before items when
+// the lock was acquired
+func (q *Queue) unlock(before int) {
+	hook := q.transitionHook(before)
+	q.lock.Unlock()
+
+	if hook != nil {
+		hook()
+	}
+}
+
+// ProcessAll removes items from the front of the queue and passes them to f
+// until the queue is empty. Items for which f returns an error are retried as
+// if they had been added to the end of the queue again, i.e. after all items
+// that are in the queue at the time f failed, up to maxAttempts calls of f per
+// item in total. Items waiting for a retry are held by ProcessAll, not by the
+// queue. ProcessAll returns the items that still failed after maxAttempts
+// calls, in the order they were given up. A maxAttempts below 1 is treated as
+// 1. The queue is not locked while f is called, so f may add items to the
+// queue, which are processed as well.
+func (q *Queue) ProcessAll(maxAttempts int, f func(item interface{}) error) []interface{} {
+	type retry struct {
+		item     interface{}
+		attempts int
+		due      int // number of items to take from the queue before the retry
+	}
+	var retries []retry
+	var failed []interface{}
+	taken := 0
+	for {
+		var cur retry
+		if len(retries) > 0 && retries[0].due <= taken {
+			cur, retries = retries[0], retries[1:]
+		} else if item, err := q.Remove(); err == nil {
+			cur.item = item
+			taken++
+		} else if len(retries) > 0 {
+			cur, retries = retries[0], retries[1:]
+		} else {
+			return failed
+		}
+		if f(cur.item) == nil {
+			continue
+		}
+		cur.attempts++
+		if cur.attempts < maxAttempts {
+			cur.due = taken + q.Len()
+			retries = append(retries, cur)
+		} else {
+			failed = append(failed, cur.item)
+		}
+	}
+}
+
+// PeekMatching returns all items for which pred returns true from front to back
+// without removing them. The queue is locked while pred is called, so pred must
+// not use the queue.
+func (q *Queue) PeekMatching(pred func(item interface{}) bool) []interface{} {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	var items []interface{}
+	for _, item := range q.data {
+		if pred(item) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// TryAdd adds an item at the end of the queue and returns true. The queue is
+// unbounded, so adding always succeeds. TryAdd makes Queue interchangeable with
+// bounded queues, see FixedQueue.TryAdd.
+func (q *Queue) TryAdd(item interface{}) bool {
+	q.Add(item)
+	return true
+}
+
+// WaitForLen blocks until the queue holds at least n items or the context is
+// done, in which case the context's error is returned. The items may have been
+// removed by other consumers by the time WaitForLen returns.
+func (q *Queue) WaitForLen(ctx context.Context, n int) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.waitUntil(ctx, func() bool { return len(q.data) >= n })
+}
+
+// MoveAllTo removes all items from the queue and adds them at the end of dst in
+// the same order. Both queues are locked for the whole move, so no other caller
+// can observe an item in neither or both queues. The locks are always acquired
+// in the same order, so concurrent moves between two queues in opposite
+// directions do not deadlock. If dst tracks wait times, the items are
+// timestamped when they are added to it.
+func (q *Queue) MoveAllTo(dst *Queue) {
+	if q == dst {
+		return
+	}
+	first, second := q, dst
+	if reflect.ValueOf(dst).Pointer() < reflect.ValueOf(q).Pointer() {
+		first, second = dst, q
+	}
+	first.lock.Lock()
+	second.lock.Lock()
+
+	srcBefore, dstBefore := len(q.data), len(dst.data)
+	for _, item := range q.data {
+		dst.push(item)
+	}
+	if q.now != nil {
+		q.recordWait(q.added)
+		q.added = nil
+	}
+	q.dequeued.Add(uint64(len(q.data)))
+	q.data = nil
+
+	srcHook, dstHook := q.transitionHook(srcBefore), dst.transitionHook(dstBefore)
+	second.lock.Unlock()
+	first.lock.Unlock()
+
+	if srcHook != nil {
+		srcHook()
+	}
+	if dstHook != nil {
+		dstHook()
+	}
+}
+
+// TypeHistogram returns a map from the name of each type of item in the queue,
+// as returned by reflect.Type.String, to the number of items of that type. Nil
+// items are counted as "<nil>".
+func (q *Queue) TypeHistogram() map[string]int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	histogram := make(map[string]int)
+	for _, item := range q.data {
+		name := "<nil>"
+		if t := reflect.TypeOf(item); t != nil {
+			name = t.String()
+		}
+		histogram[name]++
+	}
+	return histogram
+}
+
+// Tail returns up to n items from the back of the queue in the order they
+// would be removed, without removing them. A negative n yields no items.
+func (q *Queue) Tail(n int) []interface{} {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if n < 0 {
+		n = 0
+	}
+	if n > len(q.data) {
+		n = len(q.data)
+	}
+	items := make([]interface{}, n)
+	copy(items, q.data[len(q.data)-n:])
+	return items
+}
@@ -1,7 +1,11 @@
 package queue
 
 import (
+	"context"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -27,6 +31,33 @@ func TestLen(t *testing.T) {
 	assert.Equal(t, 0, q.Len())
 }
 
+func TestCap(t *testing.T) {
+	q := Queue{}
+	assert.Equal(t, 0, q.Cap())
+
+	q.Add(1)
+	q.Add(2)
+	assert.True(t, q.Cap() >= q.Len())
+}
+
+func TestTotals(t *testing.T) {
+	q := Queue{}
+	assert.Equal(t, uint64(0), q.TotalEnqueued())
+	assert.Equal(t, uint64(0), q.TotalDequeued())
+
+	q.Add(1)
+	q.AddLen(2)
+	q.Add(3)
+	q.Add(4)
+	_, _ = q.Remove()
+	_ = q.RemoveWhile(func(item interface{}) bool { return item.(int) < 4 })
+	_, _ = q.Remove()
+	_, _ = q.Remove() // empty queue
+	q.Compact()
+	assert.Equal(t, uint64(4), q.TotalEnqueued())
+	assert.Equal(t, uint64(4), q.TotalDequeued())
+}
+
 func TestAddRemove(t *testing.T) {
 	q := Queue{}
 	q.Add(1337)
@@ -38,6 +69,16 @@ func TestAddRemove(t *testing.T) {
 	assert.Equal(t, ErrorEmpty, err)
 }
 
+func TestAddLen(t *testing.T) {
+	q := Queue{}
+	assert.Equal(t, 1, q.AddLen(1))
+	assert.Equal(t, 2, q.AddLen(2))
+
+	_, _ = q.Remove()
+	assert.Equal(t, 2, q.AddLen(3))
+	assert.Equal(t, 2, q.Len())
+}
+
 func TestPeek(t *testing.T) {
 	q := Queue{}
 
@@ -50,3 +91,879 @@ func TestPeek(t *testing.T) {
 	assert.Equal(t, 1337, item)
 	assert.Equal(t, 1, q.Len())
 }
+
+func TestReplaceFront(t *testing.T) {
+	q := Queue{}
+
+	_, err := q.ReplaceFront(1)
+	assert.Equal(t, ErrorEmpty, err)
+	assert.Equal(t, 0, q.Len())
+
+	q.Add(1)
+	q.Add(2)
+	old, err := q.ReplaceFront(3)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, old)
+	assert.Equal(t, 2, q.Len())
+
+	item, _ := q.Remove()
+	assert.Equal(t, 3, item)
+	item, _ = q.Remove()
+	assert.Equal(t, 2, item)
+}
+
+func TestRotate(t *testing.T) {
+	q := Queue{}
+	assert.Equal(t, ErrorEmpty, q.Rotate())
+
+	q.Add(1)
+	q.Add(2)
+	q.Add(3)
+	assert.Equal(t, nil, q.Rotate())
+	assert.Equal(t, 3, q.Len())
+
+	for _, expected := range []int{2, 3, 1} {
+		item, err := q.Remove()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, expected, item)
+	}
+
+	q.Add(1)
+	assert.Equal(t, nil, q.Rotate())
+	item, _ := q.Peek()
+	assert.Equal(t, 1, item)
+}
+
+func TestSingleProducerOrder(t *testing.T) {
+	const consumers, items = 8, 10000
+
+	q := Queue{}
+	done := make(chan struct{})
+	received := make([][]int, consumers)
+	var wg sync.WaitGroup
+	for c := 0; c < consumers; c++ {
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			for {
+				item, err := q.Remove()
+				if err == ErrorEmpty {
+					select {
+					case <-done:
+						return
+					default:
+						continue
+					}
+				}
+				received[c] = append(received[c], item.(int))
+			}
+		}(c)
+	}
+	for i := 0; i < items; i++ {
+		q.Add(i)
+	}
+	close(done)
+	wg.Wait()
+
+	total := 0
+	for c, seq := range received {
+		total += len(seq)
+		for i := 1; i < len(seq); i++ {
+			if seq[i] <= seq[i-1] {
+				t.Errorf("consumer %v received `%v` after `%v`", c, seq[i], seq[i-1])
+				break
+			}
+		}
+	}
+	assert.Equal(t, items, total)
+}
+
+func TestConcurrentConsumers(t *testing.T) {
+	const producers, consumers, items = 8, 8, 1000
+
+	q := Queue{}
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < items; i++ {
+				q.Add(p*items + i)
+			}
+		}(p)
+	}
+
+	var lock sync.Mutex
+	seen := make(map[int]int)
+	done := make(chan struct{})
+	var cwg sync.WaitGroup
+	for c := 0; c < consumers; c++ {
+		cwg.Add(1)
+		go func() {
+			defer cwg.Done()
+			for {
+				item, err := q.Remove()
+				if err == ErrorEmpty {
+					select {
+					case <-done:
+						return
+					default:
+						continue
+					}
+				}
+				lock.Lock()
+				seen[item.(int)]++
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	cwg.Wait()
+
+	assert.Equal(t, 0, q.Len())
+	assert.Equal(t, producers*items, len(seen))
+	for item, n := range seen {
+		if n != 1 {
+			t.Errorf("item `%v` removed `%v` times", item, n)
+		}
+	}
+}
+
+func TestAll(t *testing.T) {
+	q := Queue{}
+	for range q.All() {
+		t.Errorf("unexpected item in empty queue")
+	}
+
+	q.Add(1)
+	q.Add(2)
+	q.Add(3)
+	var got []interface{}
+	for i, item := range q.All() {
+		assert.Equal(t, i+1, item)
+		got = append(got, item)
+		// modifying the queue during the iteration must be safe
+		_, _ = q.ReplaceFront(0)
+		q.Add(4)
+	}
+	assert.Equal(t, []interface{}{1, 2, 3}, got)
+
+	n := 0
+	for range q.All() {
+		n++
+		break
+	}
+	assert.Equal(t, 1, n)
+}
+
+func TestRemoveWhile(t *testing.T) {
+	q := Queue{}
+	small := func(item interface{}) bool { return item.(int) < 10 }
+	assert.Equal(t, []interface{}{}, q.RemoveWhile(small))
+
+	for _, item := range []int{1, 2, 10, 3} {
+		q.Add(item)
+	}
+	assert.Equal(t, []interface{}{1, 2}, q.RemoveWhile(small))
+	assert.Equal(t, 2, q.Len())
+	assert.Equal(t, []interface{}{}, q.RemoveWhile(small))
+
+	item, _ := q.Peek()
+	assert.Equal(t, 10, item)
+
+	all := func(item interface{}) bool { return true }
+	assert.Equal(t, []interface{}{10, 3}, q.RemoveWhile(all))
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestCompact(t *testing.T) {
+	q := Queue{}
+	q.Compact()
+	assert.Equal(t, 0, q.Cap())
+
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+	}
+	for i := 0; i < 90; i++ {
+		_, _ = q.Remove()
+	}
+	q.Compact()
+	assert.Equal(t, 10, q.Len())
+	assert.Equal(t, 10, q.Cap())
+	item, _ := q.Peek()
+	assert.Equal(t, 90, item)
+
+	for i := 0; i < 10; i++ {
+		_, _ = q.Remove()
+	}
+	q.Compact()
+	assert.Equal(t, 0, q.Cap())
+}
+
+func TestClone(t *testing.T) {
+	q := Queue{}
+	c := q.Clone()
+	assert.Equal(t, 0, c.Len())
+
+	q.Add(1)
+	q.Add(2)
+	c = q.Clone()
+	assert.Equal(t, 2, c.Len())
+
+	_, _ = c.ReplaceFront(3)
+	c.Add(4)
+	_, _ = q.Remove()
+	assert.Equal(t, 3, c.Len())
+	assert.Equal(t, 1, q.Len())
+
+	for _, expected := range []int{3, 2, 4} {
+		item, _ := c.Remove()
+		assert.Equal(t, expected, item)
+	}
+	item, _ := q.Remove()
+	assert.Equal(t, 2, item)
+}
+
+func TestReverse(t *testing.T) {
+	q := Queue{}
+	q.Reverse()
+	assert.Equal(t, 0, q.Len())
+
+	for i := 1; i <= 5; i++ {
+		q.Add(i)
+	}
+	q.Reverse()
+	assert.Equal(t, 5, q.Len())
+	for i := 5; i >= 1; i-- {
+		item, _ := q.Remove()
+		assert.Equal(t, i, item)
+	}
+}
+
+func TestSortBy(t *testing.T) {
+	type job struct {
+		name     string
+		priority int
+	}
+	q := Queue{}
+	q.Add(job{"a", 2})
+	q.Add(job{"b", 1})
+	q.Add(job{"c", 2})
+	q.Add(job{"d", 0})
+	q.SortBy(func(a, b interface{}) bool {
+		return a.(job).priority < b.(job).priority
+	})
+	for _, expected := range []string{"d", "b", "a", "c"} {
+		item, _ := q.Remove()
+		assert.Equal(t, expected, item.(job).name)
+	}
+}
+
+func TestPeekWait(t *testing.T) {
+	t.Run("non-empty queue", func(t *testing.T) {
+		q := Queue{}
+		q.Add(1)
+		item, err := q.PeekWait(context.Background())
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, item)
+		assert.Equal(t, 1, q.Len())
+	})
+	t.Run("wait for add", func(t *testing.T) {
+		q := Queue{}
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			q.Add(1)
+		}()
+		item, err := q.PeekWait(context.Background())
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, item)
+		assert.Equal(t, 1, q.Len())
+	})
+	t.Run("context done", func(t *testing.T) {
+		q := Queue{}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := q.PeekWait(ctx)
+		assert.Equal(t, context.DeadlineExceeded, err)
+	})
+}
+
+func TestSetGrowthPolicy(t *testing.T) {
+	q := Queue{}
+	var calls []int
+	q.SetGrowthPolicy(func(curCap int) int {
+		calls = append(calls, curCap)
+		return curCap + 4
+	})
+	for i := 0; i < 9; i++ {
+		q.Add(i)
+	}
+	assert.Equal(t, []int{0, 4, 8}, calls)
+	assert.Equal(t, 12, q.Cap())
+	for i := 0; i < 9; i++ {
+		item, _ := q.Remove()
+		assert.Equal(t, i, item)
+	}
+
+	// results too small to hold the new item are ignored
+	q.SetGrowthPolicy(func(curCap int) int { return 0 })
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, i+1, q.AddLen(i))
+	}
+	assert.Equal(t, nil, q.Rotate())
+	item, _ := q.Peek()
+	assert.Equal(t, 1, item)
+
+	q.SetGrowthPolicy(nil)
+	q.Add(10)
+	assert.Equal(t, 11, q.Len())
+}
+
+func TestPartition(t *testing.T) {
+	q := Queue{}
+	for i := 1; i <= 6; i++ {
+		q.Add(i)
+	}
+	even, odd := q.Partition(func(item interface{}) bool {
+		return item.(int)%2 == 0
+	})
+	assert.Equal(t, 0, q.Len())
+	assert.Equal(t, uint64(6), q.TotalDequeued())
+	assert.Equal(t, 3, even.Len())
+	assert.Equal(t, 3, odd.Len())
+	for _, expected := range []int{2, 4, 6} {
+		item, _ := even.Remove()
+		assert.Equal(t, expected, item)
+	}
+	for _, expected := range []int{1, 3, 5} {
+		item, _ := odd.Remove()
+		assert.Equal(t, expected, item)
+	}
+
+	match, rest := q.Partition(func(item interface{}) bool { return true })
+	assert.Equal(t, 0, match.Len())
+	assert.Equal(t, 0, rest.Len())
+}
+
+func TestTruncate(t *testing.T) {
+	q := Queue{}
+	q.Truncate()
+	assert.Equal(t, 0, q.Len())
+
+	for i := 0; i < 10; i++ {
+		q.Add(i)
+	}
+	q.Remove()
+	capacity := q.Cap()
+	q.Truncate()
+	assert.Equal(t, 0, q.Len())
+	assert.Equal(t, capacity, q.Cap())
+	assert.Equal(t, uint64(10), q.TotalDequeued())
+	assert.Equal(t, q.TotalEnqueued()-q.TotalDequeued(), uint64(q.Len()))
+	_, err := q.Remove()
+	assert.Equal(t, ErrorEmpty, err)
+
+	q.Add(1)
+	item, _ := q.Peek()
+	assert.Equal(t, 1, item)
+	assert.Equal(t, capacity, q.Cap())
+}
+
+func TestIndexOf(t *testing.T) {
+	q := Queue{}
+	i, ok := q.IndexOf("a")
+	assert.Equal(t, -1, i)
+	assert.Equal(t, false, ok)
+
+	q.Add("a")
+	q.Add([]int{1, 2})
+	q.Add("b")
+	q.Add("a")
+
+	i, ok = q.IndexOf("a")
+	assert.Equal(t, 0, i)
+	assert.Equal(t, true, ok)
+	i, ok = q.IndexOf([]int{1, 2})
+	assert.Equal(t, 1, i)
+	assert.Equal(t, true, ok)
+	_, ok = q.IndexOf("c")
+	assert.Equal(t, false, ok)
+
+	i, ok = q.IndexFunc(func(item interface{}) bool {
+		s, isString := item.(string)
+		return isString && s > "a"
+	})
+	assert.Equal(t, 2, i)
+	assert.Equal(t, true, ok)
+}
+
+func TestRemoveAt(t *testing.T) {
+	q := Queue{}
+	_, err := q.RemoveAt(0)
+	assert.Equal(t, ErrorEmpty, err)
+
+	for _, item := range []string{"a", "b", "c", "d"} {
+		q.Add(item)
+	}
+	_, err = q.RemoveAt(-1)
+	assert.Equal(t, ErrorIndexOutOfRange, err)
+	_, err = q.RemoveAt(4)
+	assert.Equal(t, ErrorIndexOutOfRange, err)
+
+	item, err := q.RemoveAt(2)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "c", item)
+	item, err = q.RemoveAt(0)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "a", item)
+	item, err = q.RemoveAt(q.Len() - 1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "d", item)
+	assert.Equal(t, 1, q.Len())
+	assert.Equal(t, uint64(3), q.TotalDequeued())
+
+	item, _ = q.Remove()
+	assert.Equal(t, "b", item)
+}
+
+func TestCancelQueuedItem(t *testing.T) {
+	q := Queue{}
+	for i := 0; i < 5; i++ {
+		q.Add(i)
+	}
+	i, ok := q.IndexOf(3)
+	assert.Equal(t, true, ok)
+	item, err := q.RemoveAt(i)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, item)
+	_, ok = q.IndexOf(3)
+	assert.Equal(t, false, ok)
+	assert.Equal(t, 4, q.Len())
+}
+
+func TestReduce(t *testing.T) {
+	q := Queue{}
+	sum := func(acc, item interface{}) interface{} {
+		return acc.(int) + item.(int)
+	}
+	assert.Equal(t, 0, q.Reduce(0, sum))
+
+	for i := 1; i <= 4; i++ {
+		q.Add(i)
+	}
+	assert.Equal(t, 10, q.Reduce(0, sum))
+	assert.Equal(t, "1234", q.Reduce("", func(acc, item interface{}) interface{} {
+		return fmt.Sprintf("%v%v", acc, item)
+	}))
+	assert.Equal(t, 4, q.Len())
+	item, _ := q.Peek()
+	assert.Equal(t, 1, item)
+}
+
+func TestCopyTo(t *testing.T) {
+	q := Queue{}
+	buf := make([]interface{}, 3)
+	assert.Equal(t, 0, q.CopyTo(buf))
+
+	q.Add(1)
+	q.Add(2)
+	assert.Equal(t, 2, q.CopyTo(buf))
+	assert.Equal(t, []interface{}{1, 2, nil}, buf)
+
+	q.Add(3)
+	q.Add(4)
+	assert.Equal(t, 3, q.CopyTo(buf))
+	assert.Equal(t, []interface{}{1, 2, 3}, buf)
+	assert.Equal(t, 0, q.CopyTo(nil))
+	assert.Equal(t, 4, q.Len())
+
+	// modifying the buffer does not change the queue
+	buf[0] = "changed"
+	item, _ := q.Peek()
+	assert.Equal(t, 1, item)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		q.CopyTo(buf)
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func TestOnEmptyOnNonEmpty(t *testing.T) {
+	q := Queue{}
+	var events []string
+	q.OnNonEmpty(func() {
+		// the queue is unlocked when the hook is called
+		events = append(events, fmt.Sprintf("non-empty %v", q.Len()))
+	})
+	q.OnEmpty(func() {
+		events = append(events, fmt.Sprintf("empty %v", q.Len()))
+	})
+
+	q.Add(1)
+	q.AddLen(2)
+	q.Remove()
+	q.Remove()
+	q.Remove()
+	assert.Equal(t, []string{"non-empty 1", "empty 0"}, events)
+
+	events = nil
+	q.Add(1)
+	q.Add(2)
+	q.RemoveWhile(func(interface{}) bool { return true })
+	q.Add(3)
+	q.RemoveAt(0)
+	q.Add(4)
+	q.Truncate()
+	q.Add(5)
+	q.Partition(func(interface{}) bool { return true })
+	assert.Equal(t, []string{
+		"non-empty 1", "empty 0",
+		"non-empty 1", "empty 0",
+		"non-empty 1", "empty 0",
+		"non-empty 1", "empty 0",
+	}, events)
+
+	events = nil
+	q.OnEmpty(nil)
+	q.OnNonEmpty(nil)
+	q.Add(1)
+	q.Remove()
+	assert.Equal(t, 0, len(events))
+}
+
+func TestProcessAll(t *testing.T) {
+	q := Queue{}
+	assert.Equal(t, 0, len(q.ProcessAll(3, func(interface{}) error { return nil })))
+
+	for i := 0; i < 5; i++ {
+		q.Add(i)
+	}
+	errFailed := fmt.Errorf("failed")
+	attempts := make(map[interface{}]int)
+	var order []interface{}
+	failed := q.ProcessAll(3, func(item interface{}) error {
+		attempts[item]++
+		order = append(order, item)
+		switch {
+		case item == 1 && attempts[item] < 2:
+			return errFailed // succeeds on the second attempt
+		case item == 3:
+			return errFailed // never succeeds
+		case item == 4 && attempts[item] == 1:
+			q.Add(5) // items added while processing are processed as well
+		}
+		return nil
+	})
+	assert.Equal(t, []interface{}{3}, failed)
+	// failed items are retried after the items queued when they failed
+	assert.Equal(t, []interface{}{0, 1, 2, 3, 4, 1, 3, 5, 3}, order)
+	assert.Equal(t, 3, attempts[3])
+	assert.Equal(t, 2, attempts[1])
+	assert.Equal(t, 0, q.Len())
+
+	q.Add("x")
+	failed = q.ProcessAll(0, func(interface{}) error { return errFailed })
+	assert.Equal(t, []interface{}{"x"}, failed)
+}
+
+func TestProcessAllUncomparableItems(t *testing.T) {
+	q := Queue{}
+	q.Add([]byte("job"))
+	q.Add(map[string]int{"job": 1})
+	calls := 0
+	errFailed := fmt.Errorf("failed")
+	failed := q.ProcessAll(2, func(item interface{}) error {
+		calls++
+		if _, ok := item.([]byte); ok {
+			return errFailed
+		}
+		return nil
+	})
+	assert.Equal(t, []interface{}{[]byte("job")}, failed)
+	assert.Equal(t, 3, calls)
+}
+
+func TestProcessAllDuplicateItems(t *testing.T) {
+	q := Queue{}
+	q.Add("mail")
+	q.Add("mail")
+	calls := 0
+	failed := q.ProcessAll(2, func(item interface{}) error {
+		calls++
+		if calls <= 2 {
+			return fmt.Errorf("failed") // the first attempt of each item
+		}
+		return nil
+	})
+	// equal items count their attempts separately, so both succeed on the
+	// second attempt
+	assert.Equal(t, 0, len(failed))
+	assert.Equal(t, 4, calls)
+
+	q.Add("mail")
+	q.Add("mail")
+	failed = q.ProcessAll(2, func(item interface{}) error {
+		return fmt.Errorf("failed")
+	})
+	assert.Equal(t, []interface{}{"mail", "mail"}, failed)
+}
+
+func TestPeekMatching(t *testing.T) {
+	q := Queue{}
+	even := func(item interface{}) bool { return item.(int)%2 == 0 }
+	assert.Equal(t, 0, len(q.PeekMatching(even)))
+
+	for i := 0; i < 7; i++ {
+		q.Add(i)
+	}
+	assert.Equal(t, []interface{}{0, 2, 4, 6}, q.PeekMatching(even))
+	assert.Equal(t, 0, len(q.PeekMatching(func(interface{}) bool { return false })))
+	assert.Equal(t, 7, q.Len())
+	item, _ := q.Peek()
+	assert.Equal(t, 0, item)
+}
+
+func TestTryAdd(t *testing.T) {
+	q := Queue{}
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, true, q.TryAdd(i))
+	}
+	assert.Equal(t, 100, q.Len())
+	item, _ := q.Peek()
+	assert.Equal(t, 0, item)
+}
+
+func TestWaitForLen(t *testing.T) {
+	t.Run("reached", func(t *testing.T) {
+		q := Queue{}
+		q.Add(0)
+		go func() {
+			for i := 1; i < 5; i++ {
+				time.Sleep(time.Millisecond)
+				q.Add(i)
+			}
+		}()
+		assert.Equal(t, nil, q.WaitForLen(context.Background(), 5))
+		assert.Equal(t, 5, q.Len())
+	})
+
+	t.Run("already reached", func(t *testing.T) {
+		q := Queue{}
+		assert.Equal(t, nil, q.WaitForLen(context.Background(), 0))
+		q.Add(1)
+		q.Add(2)
+		assert.Equal(t, nil, q.WaitForLen(context.Background(), 2))
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		q := Queue{}
+		q.Add(1)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.Equal(t, context.DeadlineExceeded, q.WaitForLen(ctx, 2))
+	})
+}
+
+func TestMoveAllTo(t *testing.T) {
+	t.Run("order", func(t *testing.T) {
+		src, dst := &Queue{}, &Queue{}
+		dst.Add("x")
+		for i := 0; i < 3; i++ {
+			src.Add(i)
+		}
+		var events []string
+		src.OnEmpty(func() { events = append(events, "src empty") })
+		dst.OnNonEmpty(func() { events = append(events, "dst non-empty") })
+
+		src.MoveAllTo(dst)
+		assert.Equal(t, 0, src.Len())
+		assert.Equal(t, uint64(3), src.TotalDequeued())
+		assert.Equal(t, uint64(4), dst.TotalEnqueued())
+		assert.Equal(t, []interface{}{"x", 0, 1, 2}, dst.PeekMatching(func(interface{}) bool { return true }))
+		assert.Equal(t, []string{"src empty"}, events)
+
+		events = nil
+		dst.Truncate()
+		src.Add(1)
+		src.MoveAllTo(dst)
+		assert.Equal(t, []string{"src empty", "dst non-empty"}, events)
+
+		// moving into the queue itself is a no-op
+		dst.MoveAllTo(dst)
+		assert.Equal(t, 1, dst.Len())
+	})
+
+	t.Run("concurrent opposite directions", func(t *testing.T) {
+		a, b := &Queue{}, &Queue{}
+		for i := 0; i < 100; i++ {
+			a.Add(i)
+		}
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					a.MoveAllTo(b)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					b.MoveAllTo(a)
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 100, a.Len()+b.Len())
+	})
+}
+
+func TestTypeHistogram(t *testing.T) {
+	q := Queue{}
+	assert.Equal(t, map[string]int{}, q.TypeHistogram())
+
+	q.Add(1)
+	q.Add(2)
+	q.Add("a")
+	q.Add(nil)
+	q.Add(&Queue{})
+	q.Add([]int{1})
+	assert.Equal(t, map[string]int{
+		"int":          2,
+		"string":       1,
+		"<nil>":        1,
+		"*queue.Queue": 1,
+		"[]int":        1,
+	}, q.TypeHistogram())
+	assert.Equal(t, 6, q.Len())
+}
+
+func TestTail(t *testing.T) {
+	q := Queue{}
+	assert.Equal(t, []interface{}{}, q.Tail(3))
+
+	for i := 0; i < 5; i++ {
+		q.Add(i)
+	}
+	assert.Equal(t, []interface{}{2, 3, 4}, q.Tail(3))
+	assert.Equal(t, []interface{}{0, 1, 2, 3, 4}, q.Tail(10))
+	assert.Equal(t, []interface{}{}, q.Tail(0))
+	assert.Equal(t, []interface{}{}, q.Tail(-1))
+
+	// the tail is a copy
+	tail := q.Tail(1)
+	tail[0] = "changed"
+	assert.Equal(t, []interface{}{4}, q.Tail(1))
+	assert.Equal(t, 5, q.Len())
+}
+
+func TestAverageWait(t *testing.T) {
+	now := time.Unix(0, 0)
+	q := New(true)
+	q.now = func() time.Time { return now }
+
+	_, err := q.Remove()
+	assert.Equal(t, ErrorEmpty, err)
+	assert.Equal(t, time.Duration(0), q.AverageWait())
+
+	q.Add(1)
+	now = now.Add(time.Second)
+	q.Add(2)
+	q.Add(3)
+	assert.Equal(t, 3, q.Len())
+
+	now = now.Add(time.Second)
+	item, err := q.Remove()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, item)
+	assert.Equal(t, 2*time.Second, q.AverageWait())
+
+	item, _ = q.Remove()
+	assert.Equal(t, 2, item)
+	// (2s + 1s) / 2
+	assert.Equal(t, 1500*time.Millisecond, q.AverageWait())
+
+	now = now.Add(3 * time.Second)
+	item, _ = q.Remove()
+	assert.Equal(t, 3, item)
+	// (2s + 1s + 4s) / 3
+	assert.Equal(t, 7*time.Second/3, q.AverageWait())
+	assert.Equal(t, 0, q.Len())
+
+	// wait times are not tracked by default
+	for _, q := range []*Queue{{}, New(false)} {
+		q.Add(1)
+		q.Remove()
+		assert.Equal(t, time.Duration(0), q.AverageWait())
+	}
+}
+
+func TestAverageWaitReordered(t *testing.T) {
+	now := time.Unix(0, 0)
+	q := New(true)
+	q.now = func() time.Time { return now }
+	// item i is added at second i
+	for i := 0; i < 5; i++ {
+		q.Add(i)
+		now = now.Add(time.Second)
+	}
+	now = time.Unix(10, 0)
+
+	q.Reverse() // 4 3 2 1 0
+	q.Rotate()  // 3 2 1 0 4
+	q.SortBy(func(a, b interface{}) bool {
+		return a.(int) < b.(int)
+	})
+	item, _ := q.RemoveAt(3)
+	assert.Equal(t, 3, item)
+	assert.Equal(t, 7*time.Second, q.AverageWait())
+
+	// 0 is replaced after waiting 10s, 5 and 1 are removed after 0s and 9s
+	item, _ = q.ReplaceFront(5)
+	assert.Equal(t, 0, item)
+	assert.Equal(t, []interface{}{5, 1}, q.RemoveWhile(func(item interface{}) bool {
+		return item != 2
+	}))
+	// (7s + 10s + 0s + 9s) / 4
+	assert.Equal(t, 26*time.Second/4, q.AverageWait())
+
+	c := q.Clone()
+	q.Truncate()
+	c.now = q.now
+	item, _ = c.Remove()
+	assert.Equal(t, 2, item)
+	assert.Equal(t, 8*time.Second, c.AverageWait())
+	assert.Equal(t, 26*time.Second/4, q.AverageWait())
+}
+
+func TestAverageWaitMoved(t *testing.T) {
+	now := time.Unix(0, 0)
+	clock := func() time.Time { return now }
+	q, dst := New(true), New(true)
+	q.now, dst.now = clock, clock
+	for i := 0; i < 4; i++ {
+		q.Add(i)
+	}
+	q.Compact()
+
+	now = now.Add(time.Second)
+	match, rest := q.Partition(func(item interface{}) bool {
+		return item.(int)%2 == 0
+	})
+	assert.Equal(t, time.Second, q.AverageWait())
+	match.now = clock
+	now = now.Add(time.Second)
+	match.MoveAllTo(dst)
+	assert.Equal(t, time.Second, match.AverageWait())
+	assert.Equal(t, 2, dst.Len())
+
+	data, err := rest.GobEncode()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, dst.GobDecode(data))
+	now = now.Add(time.Second)
+	item, _ := dst.Remove()
+	assert.Equal(t, 1, item)
+	assert.Equal(t, time.Second, dst.AverageWait())
+}
@@ -0,0 +1,48 @@
+package queue
+
+// ring is a ring buffer of fixed size. It is allocated once on creation, so
+// adding and removing items never allocates. It is not safe for concurrent use.
+type ring struct {
+	data   []interface{}
+	head   int // index of the first item
+	length int
+}
+
+// newRing creates a new ring buffer that holds up to size items. The size must
+// not be negative.
+func newRing(size int) ring {
+	return ring{
+		data: make([]interface{}, size),
+	}
+}
+
+// full returns true if there is no space left
+func (r *ring) full() bool {
+	return r.length == len(r.data)
+}
+
+// push adds an item at the end. The ring must not be full.
+func (r *ring) push(item interface{}) {
+	r.data[(r.head+r.length)%len(r.data)] = item
+	r.length++
+}
+
+// peek returns the first item or ErrorEmpty
+func (r *ring) peek() (interface{}, error) {
+	if r.length == 0 {
+		return nil, ErrorEmpty
+	}
+	return r.data[r.head], nil
+}
+
+// pop removes and returns the first item or returns ErrorEmpty
+func (r *ring) pop() (interface{}, error) {
+	if r.length == 0 {
+		return nil, ErrorEmpty
+	}
+	item := r.data[r.head]
+	r.data[r.head] = nil // allow the item to be garbage collected
+	r.head = (r.head + 1) % len(r.data)
+	r.length--
+	return item, nil
+}
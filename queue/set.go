@@ -0,0 +1,65 @@
+package queue
+
+import (
+	"sync"
+)
+
+// SetQueue represents a queue that holds each item at most once. Items are
+// compared using ==, so they must be of comparable types, e.g. numbers,
+// strings, pointers, or structs thereof. Adding an item of a non-comparable
+// type, e.g. a slice or a map, panics.
+type SetQueue struct {
+	lock sync.RWMutex
+	data []interface{}
+	set  map[interface{}]bool
+}
+
+// NewSetQueue creates a new set queue
+func NewSetQueue() *SetQueue {
+	return &SetQueue{
+		set: make(map[interface{}]bool),
+	}
+}
+
+// Len returns the number of items in the queue
+func (q *SetQueue) Len() int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return len(q.data)
+}
+
+// Add adds an item at the end of the queue unless an equal item is already in
+// the queue. It returns true if the item has been added.
+func (q *SetQueue) Add(item interface{}) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.set[item] {
+		return false
+	}
+	q.set[item] = true
+	q.data = append(q.data, item)
+	return true
+}
+
+// Peek returns the first item from the queue without removing it
+func (q *SetQueue) Peek() (interface{}, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if len(q.data) == 0 {
+		return nil, ErrorEmpty
+	}
+	return q.data[0], nil
+}
+
+// Remove returns the first item from the queue
+func (q *SetQueue) Remove() (interface{}, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if len(q.data) == 0 {
+		return nil, ErrorEmpty
+	}
+	item := q.data[0]
+	q.data = q.data[1:]
+	delete(q.set, item)
+	return item, nil
+}
@@ -0,0 +1,44 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetQueue(t *testing.T) {
+	q := NewSetQueue()
+	_, err := q.Peek()
+	assert.Equal(t, ErrorEmpty, err)
+	_, err = q.Remove()
+	assert.Equal(t, ErrorEmpty, err)
+
+	assert.True(t, q.Add("a"))
+	assert.True(t, q.Add("b"))
+	assert.False(t, q.Add("a"))
+	assert.True(t, q.Add(1))
+	assert.Equal(t, 3, q.Len())
+
+	item, err := q.Peek()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "a", item)
+
+	item, _ = q.Remove()
+	assert.Equal(t, "a", item)
+
+	// a removed item may be added again
+	assert.True(t, q.Add("a"))
+	assert.False(t, q.Add("b"))
+
+	for _, expected := range []interface{}{"b", 1, "a"} {
+		item, err := q.Remove()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, expected, item)
+	}
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestSetQueueNotComparable(t *testing.T) {
+	q := NewSetQueue()
+	assert.Panics(t, func() { q.Add([]int{1}) })
+}
@@ -0,0 +1,40 @@
+package queue
+
+import (
+	"context"
+)
+
+// SyncQueue represents a queue without capacity. Every item is handed over
+// directly from a producer to a consumer, like with an unbuffered channel.
+type SyncQueue struct {
+	handoff chan interface{}
+}
+
+// NewSyncQueue creates a new queue for synchronous handoff
+func NewSyncQueue() *SyncQueue {
+	return &SyncQueue{
+		handoff: make(chan interface{}),
+	}
+}
+
+// Add blocks until a consumer takes the item or the context is done. In the
+// latter case the item has not been handed over and the context's error is
+// returned.
+func (q *SyncQueue) Add(ctx context.Context, item interface{}) error {
+	select {
+	case q.handoff <- item:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Remove blocks until a producer hands over an item or the context is done
+func (q *SyncQueue) Remove(ctx context.Context) (interface{}, error) {
+	select {
+	case item := <-q.handoff:
+		return item, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,53 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncQueue(t *testing.T) {
+	t.Run("handoff", func(t *testing.T) {
+		q := NewSyncQueue()
+		taken := make(chan interface{})
+		go func() {
+			item, err := q.Remove(context.Background())
+			assert.Equal(t, nil, err)
+			taken <- item
+		}()
+		assert.Equal(t, nil, q.Add(context.Background(), "item"))
+		assert.Equal(t, "item", <-taken)
+	})
+
+	t.Run("add blocks without consumer", func(t *testing.T) {
+		q := NewSyncQueue()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.Equal(t, context.DeadlineExceeded, q.Add(ctx, "item"))
+	})
+
+	t.Run("remove blocks without producer", func(t *testing.T) {
+		q := NewSyncQueue()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		item, err := q.Remove(ctx)
+		assert.Equal(t, context.DeadlineExceeded, err)
+		assert.Equal(t, nil, item)
+	})
+
+	t.Run("order of handoffs", func(t *testing.T) {
+		q := NewSyncQueue()
+		go func() {
+			for i := 0; i < 10; i++ {
+				q.Add(context.Background(), i)
+			}
+		}()
+		for i := 0; i < 10; i++ {
+			item, err := q.Remove(context.Background())
+			assert.Equal(t, nil, err)
+			assert.Equal(t, i, item)
+		}
+	})
+}
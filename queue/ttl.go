@@ -0,0 +1,89 @@
+package queue
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ttlItem is an item of a TTL queue
+type ttlItem struct {
+	item  interface{}
+	added time.Time
+}
+
+// TTLQueue represents a queue in which items expire after a fixed time to live.
+// Expired items are discarded lazily by Peek and Remove.
+type TTLQueue struct {
+	lock sync.RWMutex
+	data []ttlItem
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// NewTTLQueue creates a new queue in which items expire after ttl
+func NewTTLQueue(ttl time.Duration) *TTLQueue {
+	return &TTLQueue{
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// expired returns the number of expired items at the front of the queue. Since
+// items are added in chronological order, all expired items are at the front.
+func (q *TTLQueue) expired() int {
+	deadline := q.now().Add(-q.ttl)
+	return sort.Search(len(q.data), func(i int) bool {
+		return q.data[i].added.After(deadline)
+	})
+}
+
+// reap discards all expired items
+func (q *TTLQueue) reap() {
+	n := q.expired()
+	for i := 0; i < n; i++ {
+		q.data[i] = ttlItem{} // allow the item to be garbage collected
+	}
+	q.data = q.data[n:]
+}
+
+// Len returns the number of items in the queue that have not expired yet
+func (q *TTLQueue) Len() int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return len(q.data) - q.expired()
+}
+
+// Add adds an item at the end of the queue
+func (q *TTLQueue) Add(item interface{}) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.data = append(q.data, ttlItem{
+		item:  item,
+		added: q.now(),
+	})
+}
+
+// Peek returns the first item that has not expired yet without removing it
+func (q *TTLQueue) Peek() (interface{}, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.reap()
+	if len(q.data) == 0 {
+		return nil, ErrorEmpty
+	}
+	return q.data[0].item, nil
+}
+
+// Remove returns the first item that has not expired yet
+func (q *TTLQueue) Remove() (interface{}, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.reap()
+	if len(q.data) == 0 {
+		return nil, ErrorEmpty
+	}
+	item := q.data[0].item
+	q.data = q.data[1:]
+	return item, nil
+}
@@ -0,0 +1,44 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTTLQueue(t *testing.T) {
+	now := time.Unix(0, 0)
+	q := NewTTLQueue(time.Minute)
+	q.now = func() time.Time { return now }
+
+	_, err := q.Peek()
+	assert.Equal(t, ErrorEmpty, err)
+	_, err = q.Remove()
+	assert.Equal(t, ErrorEmpty, err)
+
+	q.Add(1)
+	now = now.Add(30 * time.Second)
+	q.Add(2)
+	q.Add(3)
+	assert.Equal(t, 3, q.Len())
+
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, 2, q.Len())
+	item, err := q.Peek()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, item)
+
+	item, err = q.Remove()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, item)
+
+	now = now.Add(time.Hour)
+	assert.Equal(t, 0, q.Len())
+	_, err = q.Remove()
+	assert.Equal(t, ErrorEmpty, err)
+
+	q.Add(4)
+	item, _ = q.Remove()
+	assert.Equal(t, 4, item)
+}
@@ -0,0 +1,119 @@
+package queue
+
+import (
+	"sort"
+	"sync"
+)
+
+// weightedBand is a priority band of a weighted queue
+type weightedBand struct {
+	id      int
+	weight  int
+	current int // smooth weighted round-robin state
+	data    []interface{}
+}
+
+// WeightedQueue represents a queue with several priority bands that share the
+// Remove calls according to their weights. A band with weight 3 gets three
+// times as many items removed as a band with weight 1, as long as both hold
+// items, but no band starves. Within a band, items are removed in the order
+// they were added. Bands without items are skipped, so their share goes to the
+// other bands.
+type WeightedQueue struct {
+	lock  sync.RWMutex
+	bands []*weightedBand
+	len   int
+}
+
+// NewWeightedQueue creates a new queue with one band per entry of weights,
+// mapping the band to its weight. Weights below 1 are treated as 1.
+func NewWeightedQueue(weights map[int]int) *WeightedQueue {
+	q := &WeightedQueue{}
+	for id, weight := range weights {
+		if weight < 1 {
+			weight = 1
+		}
+		q.bands = append(q.bands, &weightedBand{id: id, weight: weight})
+	}
+	// break ties between bands of equal weight deterministically
+	sort.Slice(q.bands, func(i, j int) bool {
+		return q.bands[i].id > q.bands[j].id
+	})
+	return q
+}
+
+// Len returns the number of items in all bands of the queue
+func (q *WeightedQueue) Len() int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.len
+}
+
+// Add adds an item at the end of a band of the queue or returns
+// ErrorUnknownBand if there is no such band
+func (q *WeightedQueue) Add(item interface{}, band int) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for _, b := range q.bands {
+		if b.id == band {
+			b.data = append(b.data, item)
+			q.len++
+			return nil
+		}
+	}
+	return ErrorUnknownBand
+}
+
+// next returns the band the next item is removed from using smooth weighted
+// round-robin among the bands holding items, and the total weight of those
+// bands. The queue must not be empty. The caller must hold the lock.
+func (q *WeightedQueue) next() (*weightedBand, int) {
+	var best *weightedBand
+	total := 0
+	for _, b := range q.bands {
+		if len(b.data) == 0 {
+			continue
+		}
+		total += b.weight
+		if best == nil || b.current+b.weight > best.current+best.weight {
+			best = b
+		}
+	}
+	return best, total
+}
+
+// Peek returns the item that is removed next without removing it
+func (q *WeightedQueue) Peek() (interface{}, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if q.len == 0 {
+		return nil, ErrorEmpty
+	}
+	b, _ := q.next()
+	return b.data[0], nil
+}
+
+// Remove returns the first item of the band that is next in turn
+func (q *WeightedQueue) Remove() (interface{}, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.len == 0 {
+		return nil, ErrorEmpty
+	}
+	best, total := q.next()
+	for _, b := range q.bands {
+		if len(b.data) > 0 {
+			b.current += b.weight
+		}
+	}
+	best.current -= total
+
+	item := best.data[0]
+	best.data[0] = nil // allow the item to be garbage collected
+	best.data = best.data[1:]
+	q.len--
+	if len(best.data) == 0 {
+		best.current = 0
+	}
+	return item, nil
+}
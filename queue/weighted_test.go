@@ -0,0 +1,62 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightedQueue(t *testing.T) {
+	const high, normal, low = 2, 1, 0
+	q := NewWeightedQueue(map[int]int{high: 3, normal: 1, low: 1})
+
+	_, err := q.Peek()
+	assert.Equal(t, ErrorEmpty, err)
+	_, err = q.Remove()
+	assert.Equal(t, ErrorEmpty, err)
+	assert.Equal(t, ErrorUnknownBand, q.Add("x", 7))
+
+	for i := 0; i < 30; i++ {
+		assert.Equal(t, nil, q.Add(high, high))
+		assert.Equal(t, nil, q.Add(normal, normal))
+		assert.Equal(t, nil, q.Add(low, low))
+	}
+	assert.Equal(t, 90, q.Len())
+
+	// while all bands hold items, they share the removals 3:1:1
+	counts := make(map[interface{}]int)
+	for i := 0; i < 50; i++ {
+		peeked, _ := q.Peek()
+		item, err := q.Remove()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, peeked, item)
+		counts[item]++
+	}
+	assert.Equal(t, map[interface{}]int{high: 30, normal: 10, low: 10}, counts)
+
+	// with the high band exhausted, the others share the removals 1:1
+	counts = make(map[interface{}]int)
+	for i := 0; i < 20; i++ {
+		item, _ := q.Remove()
+		counts[item]++
+	}
+	assert.Equal(t, map[interface{}]int{normal: 10, low: 10}, counts)
+	assert.Equal(t, 20, q.Len())
+}
+
+func TestWeightedQueueOrder(t *testing.T) {
+	q := NewWeightedQueue(map[int]int{1: 2, 0: 0})
+	for _, item := range []string{"a", "b", "c"} {
+		q.Add("high "+item, 1)
+		q.Add("low "+item, 0)
+	}
+	var got []interface{}
+	for q.Len() > 0 {
+		item, _ := q.Remove()
+		got = append(got, item)
+	}
+	// items keep their order within a band, a weight of 0 is treated as 1
+	assert.Equal(t, []interface{}{
+		"high a", "low a", "high b", "high c", "low b", "low c",
+	}, got)
+}